@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"expense-tracker/internal/handler"
 	"expense-tracker/internal/repository"
 	"expense-tracker/internal/service"
+	"expense-tracker/internal/stream"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,7 +21,8 @@ func main() {
 	// Initialize Layers with our in-memory datastore
 	// This circumvents the need to setup PostgreSQL locally for runtime evaluation
 	repo := repository.NewRepository(nil)
-	svc := service.NewExpenseService(repo)
+	hub := stream.NewHub()
+	svc := service.NewExpenseService(repo, hub)
 	h := handler.NewHandler(svc)
 
 	// Setup Gin Router
@@ -43,10 +50,18 @@ func main() {
 		api.GET("/users", h.GetUsers)
 		api.POST("/groups", h.CreateGroup)
 		api.GET("/groups", h.GetGroups)
+		api.GET("/groups/:id/members", h.GetGroupMembers)
+		api.POST("/groups/:id/members", h.AddGroupMember)
+		api.GET("/groups/:id/expenses", h.ListExpenses)
 		api.POST("/groups/:id/expenses", h.AddExpense)
 		api.GET("/groups/:id/balances", h.GetBalances)
 		api.GET("/groups/:id/settlements", h.GetSettlements)
+		api.POST("/groups/:id/payments", h.RecordPayment)
+		api.GET("/groups/:id/stream", h.StreamGroup)
+		api.GET("/groups/:id/analytics", h.GetGroupAnalytics)
+		api.GET("/users/:id/analytics", h.GetUserAnalytics)
 		api.GET("/activities", h.GetActivities)
+		api.POST("/activities/read", h.MarkActivitiesRead)
 	}
 
 	// Start Server
@@ -55,8 +70,28 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s (In-Memory DB Mode)", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+	// Shutdown waits for open requests, so end the live streams as soon as it starts
+	srv.RegisterOnShutdown(hub.Close)
+
+	go func() {
+		log.Printf("Server starting on port %s (In-Memory DB Mode)", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 }
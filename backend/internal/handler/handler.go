@@ -1,14 +1,27 @@
 package handler
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
 	"expense-tracker/internal/service"
+	"expense-tracker/internal/stream"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// streamHeartbeat keeps idle streams from being cut by proxies and lets the server notice
+	// clients that have gone away.
+	streamHeartbeat = 15 * time.Second
+	// streamRetry tells clients how long to wait before reconnecting.
+	streamRetry = 3 * time.Second
+)
+
 type Handler struct {
 	svc *service.ExpenseService
 }
@@ -50,8 +63,9 @@ func (h *Handler) GetUsers(c *gin.Context) {
 
 func (h *Handler) CreateGroup(c *gin.Context) {
 	var req struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
+		Name        string  `json:"name" binding:"required"`
+		Description string  `json:"description"`
+		MemberIDs   []int64 `json:"member_ids"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -59,7 +73,7 @@ func (h *Handler) CreateGroup(c *gin.Context) {
 		return
 	}
 
-	group, err := h.svc.CreateGroup(req.Name, req.Description)
+	group, err := h.svc.CreateGroup(req.Name, req.Description, req.MemberIDs)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
 		return
@@ -79,6 +93,54 @@ func (h *Handler) GetGroups(c *gin.Context) {
 	c.JSON(http.StatusOK, groups)
 }
 
+func (h *Handler) AddGroupMember(c *gin.Context) {
+	groupIDStr := c.Param("id")
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req struct {
+		UserID int64 `json:"user_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.AddGroupMember(groupID, req.UserID); err != nil {
+		if err == service.ErrGroupNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add group member"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetGroupMembers(c *gin.Context) {
+	groupIDStr := c.Param("id")
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	members, err := h.svc.GetGroupMembers(groupID)
+	if err != nil {
+		if err == service.ErrGroupNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group members"})
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
 func (h *Handler) AddExpense(c *gin.Context) {
 	groupIDStr := c.Param("id")
 	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
@@ -87,8 +149,11 @@ func (h *Handler) AddExpense(c *gin.Context) {
 		return
 	}
 
+	// payers lists each contribution when several people paid; payer_id alone means that
+	// user paid the whole amount
 	var req struct {
-		PayerID     int64                `json:"payer_id" binding:"required"`
+		PayerID     int64                `json:"payer_id" binding:"required_without=Payers"`
+		Payers      []model.ExpensePayer `json:"payers"`
 		Amount      int64                `json:"amount" binding:"required"`
 		Description string               `json:"description" binding:"required"`
 		Splits      []model.ExpenseSplit `json:"splits" binding:"required"`
@@ -108,14 +173,80 @@ func (h *Handler) AddExpense(c *gin.Context) {
 		return
 	}
 
-	expense, err := h.svc.AddExpense(groupID, req.PayerID, req.Amount, req.Description, req.Splits)
+	if len(req.Payers) > 0 {
+		// Count down from the amount so that huge contributions can't wrap around to it
+		remaining := req.Amount
+		seen := make(map[int64]bool)
+		for _, p := range req.Payers {
+			if p.UserID == 0 || p.Amount <= 0 || seen[p.UserID] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Each payer must appear once with a positive amount"})
+				return
+			}
+			if p.Amount > remaining {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Sum of payers must equal the total amount"})
+				return
+			}
+			seen[p.UserID] = true
+			remaining -= p.Amount
+		}
+		if remaining != 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sum of payers must equal the total amount"})
+			return
+		}
+		if req.PayerID != 0 && !seen[req.PayerID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "payer_id must be one of the payers"})
+			return
+		}
+	}
+
+	expense, err := h.svc.AddExpense(groupID, req.PayerID, req.Amount, req.Description, req.Payers, req.Splits)
 	if err != nil {
+		if err == service.ErrNotGroupMember {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add expense"})
 		return
 	}
 	c.JSON(http.StatusCreated, expense)
 }
 
+func (h *Handler) RecordPayment(c *gin.Context) {
+	groupIDStr := c.Param("id")
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req struct {
+		FromUserID int64  `json:"from_user_id" binding:"required"`
+		ToUserID   int64  `json:"to_user_id" binding:"required"`
+		Amount     int64  `json:"amount" binding:"required,gt=0"`
+		Note       string `json:"note"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.svc.RecordPayment(groupID, req.FromUserID, req.ToUserID, req.Amount, req.Note)
+	if err != nil {
+		if err == service.ErrSelfPayment {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrNotGroupMember {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+	c.JSON(http.StatusCreated, payment)
+}
+
 func (h *Handler) GetBalances(c *gin.Context) {
 	groupIDStr := c.Param("id")
 	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
@@ -152,14 +283,223 @@ func (h *Handler) GetSettlements(c *gin.Context) {
 	c.JSON(http.StatusOK, settlements)
 }
 
-func (h *Handler) GetActivities(c *gin.Context) {
-	expenses, err := h.svc.GetAllExpenses()
+func (h *Handler) ListExpenses(c *gin.Context) {
+	groupIDStr := c.Param("id")
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get activities"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req struct {
+		PayerID       int64      `form:"payer_id"`
+		ParticipantID int64      `form:"participant_id"`
+		From          *time.Time `form:"from"`
+		To            *time.Time `form:"to"`
+		MinAmount     *int64     `form:"min_amount"`
+		MaxAmount     *int64     `form:"max_amount"`
+		Search        string     `form:"q"`
+		Sort          string     `form:"sort" binding:"omitempty,oneof=date_desc date_asc amount_desc amount_asc"`
+		Cursor        string     `form:"cursor"`
+		Limit         int        `form:"limit" binding:"omitempty,gte=1,lte=100"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expenses, nextCursor, err := h.svc.ListExpenses(repository.ExpenseFilter{
+		GroupID:       groupID,
+		PayerID:       req.PayerID,
+		ParticipantID: req.ParticipantID,
+		From:          req.From,
+		To:            req.To,
+		MinAmount:     req.MinAmount,
+		MaxAmount:     req.MaxAmount,
+		Search:        req.Search,
+		Sort:          req.Sort,
+		Cursor:        req.Cursor,
+		Limit:         req.Limit,
+	})
+	if err != nil {
+		if err == repository.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list expenses"})
 		return
 	}
 	if expenses == nil {
 		expenses = []model.Expense{}
 	}
-	c.JSON(http.StatusOK, expenses)
+	c.JSON(http.StatusOK, gin.H{"expenses": expenses, "next_cursor": nextCursor})
+}
+
+type analyticsQuery struct {
+	Interval string     `form:"interval" binding:"omitempty,oneof=day week month"`
+	From     *time.Time `form:"from"`
+	To       *time.Time `form:"to"`
+}
+
+func (h *Handler) GetGroupAnalytics(c *gin.Context) {
+	groupIDStr := c.Param("id")
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	h.spendingSeries(c, repository.SpendingQuery{GroupID: groupID})
+}
+
+func (h *Handler) GetUserAnalytics(c *gin.Context) {
+	userIDStr := c.Param("id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	h.spendingSeries(c, repository.SpendingQuery{UserID: userID})
+}
+
+func (h *Handler) spendingSeries(c *gin.Context, query repository.SpendingQuery) {
+	var req analyticsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	query.Interval = req.Interval
+	if query.Interval == "" {
+		query.Interval = "month"
+	}
+	query.From = req.From
+	query.To = req.To
+
+	series, err := h.svc.GetSpendingSeries(query)
+	if err != nil {
+		if err == repository.ErrInvalidInterval || err == repository.ErrRangeTooLarge {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"interval": query.Interval, "series": series})
+}
+
+// GetActivities returns the activity feed, newest first. group_id limits it to one group;
+// user_id reads it as that user, limited to their groups and with unread markers.
+func (h *Handler) GetActivities(c *gin.Context) {
+	var req struct {
+		GroupID int64  `form:"group_id"`
+		UserID  int64  `form:"user_id"`
+		Cursor  string `form:"cursor"`
+		Limit   int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	activities, nextCursor, unread, err := h.svc.ListActivities(repository.ActivityFilter{
+		GroupID:  req.GroupID,
+		ReaderID: req.UserID,
+		Cursor:   req.Cursor,
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		if err == repository.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get activities"})
+		return
+	}
+	if activities == nil {
+		activities = []model.Activity{}
+	}
+	c.JSON(http.StatusOK, gin.H{"activities": activities, "next_cursor": nextCursor, "unread_count": unread})
+}
+
+// MarkActivitiesRead marks a group's activities as read by a user, up to activity_id or, if
+// it is omitted, up to the newest one.
+func (h *Handler) MarkActivitiesRead(c *gin.Context) {
+	var req struct {
+		UserID     int64 `json:"user_id" binding:"required"`
+		GroupID    int64 `json:"group_id" binding:"required"`
+		ActivityID int64 `json:"activity_id" binding:"gte=0"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.MarkActivitiesRead(req.UserID, req.GroupID, req.ActivityID); err != nil {
+		if err == service.ErrNotGroupMember {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark activities as read"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// StreamGroup serves a Server-Sent Events stream of the group's expenses, payments and
+// balances. It starts with the current balances, so a client that reconnects is up to date.
+// Unlike the main server, this one keeps no backlog and ignores Last-Event-ID: events missed
+// while disconnected are not replayed, since the fresh balances already account for them.
+func (h *Handler) StreamGroup(c *gin.Context) {
+	groupIDStr := c.Param("id")
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	sub, balances, err := h.svc.SubscribeGroup(groupID)
+	if err != nil {
+		if err == service.ErrGroupNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == stream.ErrClosed {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open stream"})
+		return
+	}
+	defer h.svc.UnsubscribeGroup(sub)
+
+	c.Header("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+	c.SSEvent(service.StreamBalances, balances)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-sub.Events():
+			// Closed on shutdown or when the client fell too far behind; it reconnects and starts over
+			if ok {
+				c.SSEvent(e.Type, e.Data)
+			}
+			return ok
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		}
+	})
 }
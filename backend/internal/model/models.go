@@ -23,12 +23,19 @@ type GroupMember struct {
 }
 
 type Expense struct {
-	ID          int64     `db:"id" json:"id"`
-	GroupID     int64     `db:"group_id" json:"group_id"`
-	PayerID     int64     `db:"payer_id" json:"payer_id"`
-	Amount      int64     `db:"amount" json:"amount"` // Integer cents
-	Description string    `db:"description" json:"description"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	ID          int64          `db:"id" json:"id"`
+	GroupID     int64          `db:"group_id" json:"group_id"`
+	PayerID     int64          `db:"payer_id" json:"payer_id"` // The payer who paid the most; see Payers
+	Amount      int64          `db:"amount" json:"amount"`     // Integer cents
+	Description string         `db:"description" json:"description"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	Payers      []ExpensePayer `json:"payers,omitempty"`
+}
+
+// ExpensePayer is one user's contribution towards paying an expense
+type ExpensePayer struct {
+	UserID int64 `db:"user_id" json:"user_id"`
+	Amount int64 `db:"amount" json:"amount"` // Integer cents
 }
 
 type ExpenseSplit struct {
@@ -50,3 +57,57 @@ type UserBalance struct {
 	UserID     int64 `json:"user_id"`
 	NetBalance int64 `json:"net_balance"` // Positive means they are owed money, negative means they owe money
 }
+
+// Payment represents a recorded settle-up payment between two users in a group
+type Payment struct {
+	ID         int64     `db:"id" json:"id"`
+	GroupID    int64     `db:"group_id" json:"group_id"`
+	FromUserID int64     `db:"from_user_id" json:"from_user_id"`
+	ToUserID   int64     `db:"to_user_id" json:"to_user_id"`
+	Amount     int64     `db:"amount" json:"amount"` // Integer cents
+	Note       string    `db:"note" json:"note"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// SeriesPoint is one time bucket of a user's spending series
+type SeriesPoint struct {
+	Period   time.Time `json:"period"`   // Start of the bucket, in UTC
+	Paid     int64     `json:"paid"`     // Integer cents paid for expenses
+	Consumed int64     `json:"consumed"` // Integer cents of the user's splits
+	Balance  int64     `json:"balance"`  // Running net balance at the end of the bucket, including payments
+}
+
+// SpendingSeries is one user's spending over time
+type SpendingSeries struct {
+	UserID int64         `json:"user_id"`
+	Points []SeriesPoint `json:"points"`
+}
+
+// ActivityType is the kind of event in the activity feed
+type ActivityType string
+
+const (
+	ActivityExpenseAdded    ActivityType = "expense_added"
+	ActivityExpenseEdited   ActivityType = "expense_edited"
+	ActivityExpenseDeleted  ActivityType = "expense_deleted"
+	ActivityMemberJoined    ActivityType = "member_joined"
+	ActivityMemberLeft      ActivityType = "member_left"
+	ActivityPaymentRecorded ActivityType = "payment_recorded"
+	ActivityGroupRenamed    ActivityType = "group_renamed"
+)
+
+// Activity is one append-only event in a group's activity feed. IDs increase in the order
+// events happened. UserID is the member who joined or left, or the receiver of a payment.
+type Activity struct {
+	ID          int64        `db:"id" json:"id"`
+	GroupID     int64        `db:"group_id" json:"group_id"`
+	Type        ActivityType `db:"type" json:"type"`
+	ActorID     int64        `db:"actor_id" json:"actor_id"`
+	UserID      int64        `db:"user_id" json:"user_id,omitempty"`
+	ExpenseID   int64        `db:"expense_id" json:"expense_id,omitempty"`
+	PaymentID   int64        `db:"payment_id" json:"payment_id,omitempty"`
+	Amount      int64        `db:"amount" json:"amount,omitempty"` // Integer cents
+	Description string       `db:"description" json:"description,omitempty"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	Unread      bool         `json:"unread"` // Relative to the reader's read marker, when a reader is given
+}
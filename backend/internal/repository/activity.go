@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"expense-tracker/internal/model"
+)
+
+// ActivityFilter pages backwards through the activity feed. GroupID limits it to one group and
+// ReaderID to the groups the reader belongs to; with a reader, activities are flagged unread
+// against the reader's read markers.
+type ActivityFilter struct {
+	GroupID  int64
+	ReaderID int64
+	Cursor   string
+	Limit    int
+}
+
+// AddActivity appends an activity to the feed.
+func (r *Repository) AddActivity(activity *model.Activity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	activity.ID = r.nextActivityID
+	r.nextActivityID++
+	activity.CreatedAt = time.Now()
+
+	r.activities = append(r.activities, *activity)
+	return nil
+}
+
+// ListActivities returns one page of the feed, newest first, the cursor of the next page,
+// which is empty on the last page, and how many activities in the whole feed are unread.
+func (r *Repository) ListActivities(filter ActivityFilter) ([]model.Activity, string, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+
+	var before int64
+	if filter.Cursor != "" {
+		var err error
+		before, err = strconv.ParseInt(filter.Cursor, 10, 64)
+		if err != nil || before <= 0 {
+			return nil, "", 0, ErrInvalidCursor
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []model.Activity
+	var next string
+	unread := 0
+	for i := len(r.activities) - 1; i >= 0; i-- {
+		a := r.activities[i]
+		if filter.GroupID != 0 && a.GroupID != filter.GroupID {
+			continue
+		}
+		if filter.ReaderID != 0 {
+			if !r.groupMembers[a.GroupID][filter.ReaderID] {
+				continue
+			}
+			// Your own actions are never unread
+			a.Unread = a.ActorID != filter.ReaderID && a.ID > r.activityRead[filter.ReaderID][a.GroupID]
+			if a.Unread {
+				unread++
+			}
+		}
+
+		if before != 0 && a.ID >= before {
+			continue
+		}
+		if len(result) < filter.Limit {
+			result = append(result, a)
+		} else if next == "" {
+			next = strconv.FormatInt(result[len(result)-1].ID, 10)
+		}
+	}
+
+	return result, next, unread, nil
+}
+
+// MarkActivitiesRead moves the user's read marker for the group forward to activityID, or to
+// the group's newest activity when activityID is zero. A marker never moves backwards.
+func (r *Repository) MarkActivitiesRead(userID, groupID, activityID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if activityID == 0 {
+		for i := len(r.activities) - 1; i >= 0; i-- {
+			if r.activities[i].GroupID == groupID {
+				activityID = r.activities[i].ID
+				break
+			}
+		}
+	}
+
+	if r.activityRead[userID] == nil {
+		r.activityRead[userID] = make(map[int64]int64)
+	}
+	if activityID > r.activityRead[userID][groupID] {
+		r.activityRead[userID][groupID] = activityID
+	}
+	return nil
+}
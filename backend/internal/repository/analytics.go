@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"expense-tracker/internal/model"
+)
+
+var (
+	ErrInvalidInterval = errors.New("interval must be day, week or month")
+	ErrRangeTooLarge   = errors.New("the requested range has too many buckets for this interval")
+)
+
+// MaxSeriesPoints caps the number of buckets in a single series.
+const MaxSeriesPoints = 1000
+
+// SpendingQuery selects the spending series to compute. GroupID 0 means every group and
+// UserID 0 every user. From is rounded down to the start of its bucket; To is exclusive.
+// Interval is day, week (starting on Monday) or month, all in UTC.
+type SpendingQuery struct {
+	GroupID  int64
+	UserID   int64
+	Interval string
+	From     *time.Time
+	To       *time.Time
+}
+
+type bucketTotals struct {
+	paid, consumed, net int64
+}
+
+// GetSpendingSeries buckets what each user paid and consumed, with a running balance that
+// starts from everything before From.
+//
+// This mirrors the root server's analytics service. backend/ is a module of its own and can't
+// import that one's internal packages, and the series here is built from the in-memory maps
+// rather than SQL rows, so it keeps its own copy of the bucketing with the same boundaries.
+func (r *Repository) GetSpendingSeries(query SpendingQuery) ([]model.SpendingSeries, error) {
+	if query.Interval != "day" && query.Interval != "week" && query.Interval != "month" {
+		return nil, ErrInvalidInterval
+	}
+
+	r.mu.Lock()
+	totals := make(map[int64]map[time.Time]*bucketTotals)
+	add := func(userID int64, at time.Time, paid, consumed, net int64) {
+		if query.UserID != 0 && userID != query.UserID {
+			return
+		}
+		if query.To != nil && !at.Before(*query.To) {
+			return
+		}
+		if totals[userID] == nil {
+			totals[userID] = make(map[time.Time]*bucketTotals)
+		}
+		period := truncatePeriod(at, query.Interval)
+		t, ok := totals[userID][period]
+		if !ok {
+			t = &bucketTotals{}
+			totals[userID][period] = t
+		}
+		t.paid += paid
+		t.consumed += consumed
+		t.net += net
+	}
+
+	for _, e := range r.expenses {
+		if query.GroupID == 0 || e.GroupID == query.GroupID {
+			for _, p := range paidBy(e) {
+				add(p.UserID, e.CreatedAt, p.Amount, 0, p.Amount)
+			}
+		}
+	}
+	for _, s := range r.splits {
+		if e, ok := r.expenses[s.ExpenseID]; ok && (query.GroupID == 0 || e.GroupID == query.GroupID) {
+			add(s.UserID, e.CreatedAt, 0, s.AmountOwed, -s.AmountOwed)
+		}
+	}
+	for _, p := range r.payments {
+		if query.GroupID == 0 || p.GroupID == query.GroupID {
+			add(p.FromUserID, p.CreatedAt, 0, 0, p.Amount)
+			add(p.ToUserID, p.CreatedAt, 0, 0, -p.Amount)
+		}
+	}
+	r.mu.Unlock()
+
+	// Every series covers the same range so that they line up in a chart
+	var first, last time.Time
+	if query.From != nil {
+		first = truncatePeriod(*query.From, query.Interval)
+	}
+	if query.To != nil {
+		last = truncatePeriod(query.To.Add(-time.Nanosecond), query.Interval)
+	} else {
+		last = truncatePeriod(time.Now(), query.Interval)
+	}
+	for _, byPeriod := range totals {
+		for p := range byPeriod {
+			if query.From == nil && (first.IsZero() || p.Before(first)) {
+				first = p
+			}
+			if query.To == nil && p.After(last) {
+				last = p
+			}
+		}
+	}
+
+	result := []model.SpendingSeries{}
+	for userID, byPeriod := range totals {
+		var balance int64
+		for p, t := range byPeriod {
+			if p.Before(first) {
+				balance += t.net
+			}
+		}
+
+		points := []model.SeriesPoint{}
+		for p := first; !p.After(last); p = nextPeriod(p, query.Interval) {
+			if len(points) == MaxSeriesPoints {
+				return nil, ErrRangeTooLarge
+			}
+			point := model.SeriesPoint{Period: p}
+			if t, ok := byPeriod[p]; ok {
+				point.Paid = t.paid
+				point.Consumed = t.consumed
+				balance += t.net
+			}
+			point.Balance = balance
+			points = append(points, point)
+		}
+		result = append(result, model.SpendingSeries{UserID: userID, Points: points})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
+
+// truncatePeriod returns the start of the UTC bucket containing t.
+func truncatePeriod(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case "week":
+		// time.Weekday starts on Sunday, ISO weeks on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextPeriod(t time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
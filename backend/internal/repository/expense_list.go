@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/model"
+)
+
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ExpenseFilter filters, sorts and paginates a group's expenses. Zero values disable a filter.
+// Sort is one of date_desc (default), date_asc, amount_desc or amount_asc; ties are broken by ID.
+type ExpenseFilter struct {
+	GroupID       int64
+	PayerID       int64
+	ParticipantID int64
+	From          *time.Time
+	To            *time.Time // Exclusive
+	MinAmount     *int64
+	MaxAmount     *int64
+	Search        string
+	Sort          string
+	Cursor        string
+	Limit         int
+}
+
+type expenseCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	Amount    int64     `json:"a,omitempty"`
+	ID        int64     `json:"i"`
+}
+
+// ListExpenses returns one page of a group's expenses and the cursor of the next page,
+// which is empty on the last page.
+func (r *Repository) ListExpenses(filter ExpenseFilter) ([]model.Expense, string, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+	if filter.Sort == "" {
+		filter.Sort = "date_desc"
+	}
+
+	var cursor *expenseCursor
+	if filter.Cursor != "" {
+		data, err := base64.RawURLEncoding.DecodeString(filter.Cursor)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		cursor = &expenseCursor{}
+		// A cursor from a different sort would skip or repeat expenses
+		if err := json.Unmarshal(data, cursor); err != nil || cursor.ID == 0 || cursor.Sort != filter.Sort {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	participants := make(map[int64]bool)
+	if filter.ParticipantID != 0 {
+		for _, s := range r.splits {
+			if s.UserID == filter.ParticipantID {
+				participants[s.ExpenseID] = true
+			}
+		}
+	}
+	search := strings.ToLower(strings.TrimSpace(filter.Search))
+
+	var result []model.Expense
+	for _, e := range r.expenses {
+		switch {
+		case e.GroupID != filter.GroupID,
+			filter.PayerID != 0 && !paidByUser(e, filter.PayerID),
+			filter.ParticipantID != 0 && !participants[e.ID],
+			filter.From != nil && e.CreatedAt.Before(*filter.From),
+			filter.To != nil && !e.CreatedAt.Before(*filter.To),
+			filter.MinAmount != nil && e.Amount < *filter.MinAmount,
+			filter.MaxAmount != nil && e.Amount > *filter.MaxAmount,
+			search != "" && !strings.Contains(strings.ToLower(e.Description), search):
+			continue
+		}
+		result = append(result, *e)
+	}
+
+	less := expenseLess(filter.Sort)
+	sort.Slice(result, func(i, j int) bool {
+		return less(result[i], result[j])
+	})
+
+	// Skip everything up to and including the cursor position
+	if cursor != nil {
+		pos := model.Expense{ID: cursor.ID, CreatedAt: cursor.CreatedAt, Amount: cursor.Amount}
+		start := sort.Search(len(result), func(i int) bool {
+			return less(pos, result[i])
+		})
+		result = result[start:]
+	}
+
+	var next string
+	if len(result) > filter.Limit {
+		result = result[:filter.Limit]
+		last := result[len(result)-1]
+		data, _ := json.Marshal(expenseCursor{Sort: filter.Sort, CreatedAt: last.CreatedAt, Amount: last.Amount, ID: last.ID})
+		next = base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	return result, next, nil
+}
+
+// expenseLess returns the ordering for a sort name, falling back to newest first.
+func expenseLess(sortBy string) func(a, b model.Expense) bool {
+	switch sortBy {
+	case "date_asc":
+		return func(a, b model.Expense) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		}
+	case "amount_desc":
+		return func(a, b model.Expense) bool {
+			if a.Amount != b.Amount {
+				return a.Amount > b.Amount
+			}
+			return a.ID > b.ID
+		}
+	case "amount_asc":
+		return func(a, b model.Expense) bool {
+			if a.Amount != b.Amount {
+				return a.Amount < b.Amount
+			}
+			return a.ID < b.ID
+		}
+	default:
+		return func(a, b model.Expense) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		}
+	}
+}
+
+func paidByUser(e *model.Expense, userID int64) bool {
+	for _, p := range paidBy(e) {
+		if p.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
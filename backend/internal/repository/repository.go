@@ -2,6 +2,7 @@ package repository
 
 import (
 	"expense-tracker/internal/model"
+	"sort"
 	"sync"
 	"time"
 )
@@ -13,11 +14,16 @@ type Repository struct {
 	expenses     map[int64]*model.Expense
 	splits       map[int64]*model.ExpenseSplit
 	groupMembers map[int64]map[int64]bool
-
-	nextUserID    int64
-	nextGroupID   int64
-	nextExpenseID int64
-	nextSplitID   int64
+	payments     map[int64]*model.Payment
+	activities   []model.Activity          // Append-only, in ID order
+	activityRead map[int64]map[int64]int64 // user -> group -> last read activity ID
+
+	nextUserID     int64
+	nextGroupID    int64
+	nextExpenseID  int64
+	nextSplitID    int64
+	nextPaymentID  int64
+	nextActivityID int64
 }
 
 func NewRepository(db interface{}) *Repository {
@@ -27,11 +33,15 @@ func NewRepository(db interface{}) *Repository {
 		expenses:     make(map[int64]*model.Expense),
 		splits:       make(map[int64]*model.ExpenseSplit),
 		groupMembers: make(map[int64]map[int64]bool),
-
-		nextUserID:    1,
-		nextGroupID:   1,
-		nextExpenseID: 1,
-		nextSplitID:   1,
+		payments:     make(map[int64]*model.Payment),
+		activityRead: make(map[int64]map[int64]int64),
+
+		nextUserID:     1,
+		nextGroupID:    1,
+		nextExpenseID:  1,
+		nextSplitID:    1,
+		nextPaymentID:  1,
+		nextActivityID: 1,
 	}
 }
 
@@ -106,6 +116,26 @@ func (r *Repository) AddUserToGroup(groupID, userID int64) error {
 	return nil
 }
 
+// GetGroupMemberIDs lists a group's members in user ID order.
+func (r *Repository) GetGroupMemberIDs(groupID int64) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]int64, 0, len(r.groupMembers[groupID]))
+	for userID := range r.groupMembers[groupID] {
+		result = append(result, userID)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, nil
+}
+
+func (r *Repository) IsGroupMember(groupID, userID int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.groupMembers[groupID][userID]
+}
+
 func (r *Repository) AddExpense(expense *model.Expense, splits []model.ExpenseSplit) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -115,6 +145,7 @@ func (r *Repository) AddExpense(expense *model.Expense, splits []model.ExpenseSp
 	expense.CreatedAt = time.Now()
 
 	eCopy := *expense
+	eCopy.Payers = append([]model.ExpensePayer(nil), expense.Payers...)
 	r.expenses[expense.ID] = &eCopy
 
 	for i := range splits {
@@ -129,6 +160,19 @@ func (r *Repository) AddExpense(expense *model.Expense, splits []model.ExpenseSp
 	return nil
 }
 
+func (r *Repository) AddPayment(payment *model.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment.ID = r.nextPaymentID
+	r.nextPaymentID++
+	payment.CreatedAt = time.Now()
+
+	pCopy := *payment
+	r.payments[payment.ID] = &pCopy
+	return nil
+}
+
 func (r *Repository) GetGroupBalances(groupID int64) (map[int64]int64, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -138,7 +182,9 @@ func (r *Repository) GetGroupBalances(groupID int64) (map[int64]int64, error) {
 	// Add amounts users paid (they are creditors for this amount)
 	for _, e := range r.expenses {
 		if e.GroupID == groupID {
-			balances[e.PayerID] += e.Amount
+			for _, p := range paidBy(e) {
+				balances[p.UserID] += p.Amount
+			}
 		}
 	}
 
@@ -151,16 +197,22 @@ func (r *Repository) GetGroupBalances(groupID int64) (map[int64]int64, error) {
 		}
 	}
 
+	// Recorded payments reduce the payer's debt and the receiver's credit
+	for _, p := range r.payments {
+		if p.GroupID == groupID {
+			balances[p.FromUserID] += p.Amount
+			balances[p.ToUserID] -= p.Amount
+		}
+	}
+
 	return balances, nil
 }
 
-func (r *Repository) GetAllExpenses() ([]model.Expense, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	var result []model.Expense
-	for _, e := range r.expenses {
-		result = append(result, *e)
+// paidBy returns who paid an expense and how much. Expenses without a payers list were paid
+// in full by PayerID.
+func paidBy(e *model.Expense) []model.ExpensePayer {
+	if len(e.Payers) == 0 {
+		return []model.ExpensePayer{{UserID: e.PayerID, Amount: e.Amount}}
 	}
-	return result, nil
+	return e.Payers
 }
@@ -1,17 +1,35 @@
 package service
 
 import (
+	"errors"
+	"log"
+
 	"expense-tracker/internal/algorithm"
 	"expense-tracker/internal/model"
 	"expense-tracker/internal/repository"
+	"expense-tracker/internal/stream"
+)
+
+var (
+	ErrSelfPayment    = errors.New("a payment must be made to a different user")
+	ErrGroupNotFound  = errors.New("group not found")
+	ErrNotGroupMember = errors.New("payer and split users must be members of the group")
+)
+
+// Event types sent on a group's stream.
+const (
+	StreamExpense  = "expense"  // An expense was added; data is the activity
+	StreamPayment  = "payment"  // A payment was recorded; data is the activity
+	StreamBalances = "balances" // The group's balances after a change; data is the balance list
 )
 
 type ExpenseService struct {
 	repo *repository.Repository
+	hub  *stream.Hub
 }
 
-func NewExpenseService(repo *repository.Repository) *ExpenseService {
-	return &ExpenseService{repo: repo}
+func NewExpenseService(repo *repository.Repository, hub *stream.Hub) *ExpenseService {
+	return &ExpenseService{repo: repo, hub: hub}
 }
 
 func (s *ExpenseService) CreateUser(name, email string) (*model.User, error) {
@@ -27,34 +45,203 @@ func (s *ExpenseService) GetUsers() ([]model.User, error) {
 	return s.repo.GetUsers()
 }
 
-func (s *ExpenseService) CreateGroup(name, description string) (*model.Group, error) {
+func (s *ExpenseService) CreateGroup(name, description string, memberIDs []int64) (*model.Group, error) {
 	group := &model.Group{
 		Name:        name,
 		Description: description,
 	}
-	err := s.repo.CreateGroup(group)
-	return group, err
+	if err := s.repo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+
+	for _, userID := range memberIDs {
+		if err := s.addMember(group.ID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return group, nil
+}
+
+func (s *ExpenseService) AddGroupMember(groupID, userID int64) error {
+	group, err := s.repo.GetGroupByID(groupID)
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		return ErrGroupNotFound
+	}
+	return s.addMember(groupID, userID)
+}
+
+// GetGroupMembers lists the IDs of a group's members.
+func (s *ExpenseService) GetGroupMembers(groupID int64) ([]int64, error) {
+	group, err := s.repo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+	return s.repo.GetGroupMemberIDs(groupID)
+}
+
+// addMember adds a user to a group, announcing it in the activity feed unless they were
+// already a member. There are no accounts here, so members join on their own behalf.
+func (s *ExpenseService) addMember(groupID, userID int64) error {
+	if s.repo.IsGroupMember(groupID, userID) {
+		return nil
+	}
+	if err := s.repo.AddUserToGroup(groupID, userID); err != nil {
+		return err
+	}
+	return s.repo.AddActivity(&model.Activity{
+		GroupID: groupID,
+		Type:    model.ActivityMemberJoined,
+		ActorID: userID,
+		UserID:  userID,
+	})
 }
 
 func (s *ExpenseService) GetGroups() ([]model.Group, error) {
 	return s.repo.GetGroups()
 }
 
-func (s *ExpenseService) AddExpense(groupID, payerID int64, amount int64, description string, splits []model.ExpenseSplit) (*model.Expense, error) {
+// AddExpense records an expense. payers lists each contribution when several people paid;
+// when it is empty payerID paid the whole amount. Otherwise payerID may be zero, and the
+// person who paid the most becomes the expense's payer_id.
+func (s *ExpenseService) AddExpense(groupID, payerID int64, amount int64, description string, payers []model.ExpensePayer, splits []model.ExpenseSplit) (*model.Expense, error) {
+	if len(payers) == 0 {
+		payers = []model.ExpensePayer{{UserID: payerID, Amount: amount}}
+	}
+	if payerID == 0 {
+		top := payers[0]
+		for _, p := range payers[1:] {
+			if p.Amount > top.Amount {
+				top = p
+			}
+		}
+		payerID = top.UserID
+	}
+
+	// Payers and split users must already belong to the group; they are no longer added implicitly
+	for _, p := range payers {
+		if !s.repo.IsGroupMember(groupID, p.UserID) {
+			return nil, ErrNotGroupMember
+		}
+	}
+	for _, split := range splits {
+		if !s.repo.IsGroupMember(groupID, split.UserID) {
+			return nil, ErrNotGroupMember
+		}
+	}
+
 	expense := &model.Expense{
 		GroupID:     groupID,
 		PayerID:     payerID,
 		Amount:      amount,
 		Description: description,
+		Payers:      payers,
 	}
-	
-	s.repo.AddUserToGroup(groupID, payerID)
-	for _, split := range splits {
-		s.repo.AddUserToGroup(groupID, split.UserID)
+
+	if err := s.repo.AddExpense(expense, splits); err != nil {
+		return nil, err
 	}
 
-	err := s.repo.AddExpense(expense, splits)
-	return expense, err
+	// Without accounts the payer is taken to be whoever entered the expense
+	activity := &model.Activity{
+		GroupID:     groupID,
+		Type:        model.ActivityExpenseAdded,
+		ActorID:     payerID,
+		ExpenseID:   expense.ID,
+		Amount:      amount,
+		Description: description,
+	}
+	if err := s.repo.AddActivity(activity); err != nil {
+		return nil, err
+	}
+	s.publish(StreamExpense, activity)
+	return expense, nil
+}
+
+func (s *ExpenseService) RecordPayment(groupID, fromUserID, toUserID int64, amount int64, note string) (*model.Payment, error) {
+	if fromUserID == toUserID {
+		return nil, ErrSelfPayment
+	}
+	if !s.repo.IsGroupMember(groupID, fromUserID) || !s.repo.IsGroupMember(groupID, toUserID) {
+		return nil, ErrNotGroupMember
+	}
+
+	payment := &model.Payment{
+		GroupID:    groupID,
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     amount,
+		Note:       note,
+	}
+	if err := s.repo.AddPayment(payment); err != nil {
+		return nil, err
+	}
+
+	activity := &model.Activity{
+		GroupID:     groupID,
+		Type:        model.ActivityPaymentRecorded,
+		ActorID:     fromUserID,
+		UserID:      toUserID,
+		PaymentID:   payment.ID,
+		Amount:      amount,
+		Description: note,
+	}
+	if err := s.repo.AddActivity(activity); err != nil {
+		return nil, err
+	}
+	s.publish(StreamPayment, activity)
+	return payment, nil
+}
+
+// publish streams a change to the group's live subscribers, followed by the balances it
+// leads to. The change is already stored, so a failure here is only logged.
+func (s *ExpenseService) publish(eventType string, activity *model.Activity) {
+	if !s.hub.HasSubscribers(activity.GroupID) {
+		return
+	}
+	err := s.hub.Publish(activity.GroupID, eventType, activity)
+	if err == nil {
+		var balances []model.UserBalance
+		if balances, err = s.GetGroupBalances(activity.GroupID); err == nil {
+			err = s.hub.Publish(activity.GroupID, StreamBalances, balances)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to stream %s to group %d: %v", eventType, activity.GroupID, err)
+	}
+}
+
+// SubscribeGroup opens a live stream of the group, and returns the group's current balances
+// to send before anything from the subscription.
+func (s *ExpenseService) SubscribeGroup(groupID int64) (*stream.Subscription, []model.UserBalance, error) {
+	group, err := s.repo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if group == nil {
+		return nil, nil, ErrGroupNotFound
+	}
+
+	// Subscribe before loading the balances, so that a change in between is sent rather than lost
+	sub, err := s.hub.Subscribe(groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	balances, err := s.GetGroupBalances(groupID)
+	if err != nil {
+		s.hub.Unsubscribe(sub)
+		return nil, nil, err
+	}
+	return sub, balances, nil
+}
+
+func (s *ExpenseService) UnsubscribeGroup(sub *stream.Subscription) {
+	s.hub.Unsubscribe(sub)
 }
 
 func (s *ExpenseService) GetGroupBalances(groupID int64) ([]model.UserBalance, error) {
@@ -84,6 +271,21 @@ func (s *ExpenseService) GetGroupSettlements(groupID int64) ([]model.Transaction
 	return transactions, nil
 }
 
-func (s *ExpenseService) GetAllExpenses() ([]model.Expense, error) {
-	return s.repo.GetAllExpenses()
+func (s *ExpenseService) ListExpenses(filter repository.ExpenseFilter) ([]model.Expense, string, error) {
+	return s.repo.ListExpenses(filter)
+}
+
+func (s *ExpenseService) GetSpendingSeries(query repository.SpendingQuery) ([]model.SpendingSeries, error) {
+	return s.repo.GetSpendingSeries(query)
+}
+
+func (s *ExpenseService) ListActivities(filter repository.ActivityFilter) ([]model.Activity, string, int, error) {
+	return s.repo.ListActivities(filter)
+}
+
+func (s *ExpenseService) MarkActivitiesRead(userID, groupID, activityID int64) error {
+	if !s.repo.IsGroupMember(groupID, userID) {
+		return ErrNotGroupMember
+	}
+	return s.repo.MarkActivitiesRead(userID, groupID, activityID)
 }
@@ -0,0 +1,118 @@
+// Package stream fans live group events out to connected clients.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+var ErrClosed = errors.New("stream hub is shut down")
+
+// bufferSize events can queue up for a subscriber; one that falls further behind is
+// disconnected and starts over with the current balances when it reconnects.
+const bufferSize = 32
+
+// Event is one message on a group's stream. Data is JSON.
+type Event struct {
+	Type string
+	Data json.RawMessage
+}
+
+// Subscription receives a group's events until it is closed.
+type Subscription struct {
+	groupID int64
+	events  chan Event
+}
+
+// Events is closed when the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Hub is an in-process pub/sub of group events. Nothing is kept for replay.
+type Hub struct {
+	mu     sync.Mutex
+	groups map[int64]map[*Subscription]struct{}
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{groups: make(map[int64]map[*Subscription]struct{})}
+}
+
+func (h *Hub) Subscribe(groupID int64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+	if h.groups[groupID] == nil {
+		h.groups[groupID] = make(map[*Subscription]struct{})
+	}
+	sub := &Subscription{groupID: groupID, events: make(chan Event, bufferSize)}
+	h.groups[groupID][sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe ends a subscription. It is safe to call more than once.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// HasSubscribers reports whether anyone is listening to the group.
+func (h *Hub) HasSubscribers(groupID int64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.groups[groupID]) > 0
+}
+
+// Publish sends an event to the group's subscribers.
+func (h *Hub) Publish(groupID int64, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.groups[groupID] {
+		select {
+		case sub.events <- Event{Type: eventType, Data: payload}:
+		default:
+			h.remove(sub)
+		}
+	}
+	return nil
+}
+
+// Close ends every subscription and rejects new ones, so that open streams finish and the
+// server can shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.groups {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
+
+// remove closes a subscription. The caller must hold h.mu.
+func (h *Hub) remove(sub *Subscription) {
+	subs := h.groups[sub.groupID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.events)
+	if len(subs) == 0 {
+		delete(h.groups, sub.groupID)
+	}
+}
@@ -0,0 +1,115 @@
+// Command import loads a Splitwise export or a generic CSV file into an existing group.
+//
+//	go run ./cmd/import -group 3 -user 1 -file splitwise.csv -dry-run
+//
+// It reads the same configuration as the server and runs as the given user, who must be a
+// member of the group. The report is printed as JSON; the exit status is 1 if any row failed.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/currency"
+	"expense-tracker/internal/importer"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/service"
+	"expense-tracker/internal/storage"
+)
+
+func main() {
+	var (
+		file     = flag.String("file", "", "CSV file to import (required)")
+		groupID  = flag.Uint("group", 0, "ID of the group to import into (required)")
+		userID   = flag.Uint("user", 0, "ID of the group member the import runs as (required)")
+		format   = flag.String("format", string(importer.FormatSplitwise), "input format: splitwise or generic")
+		dryRun   = flag.Bool("dry-run", false, "validate every row and report without writing anything")
+		fallback = flag.String("currency", "", "generic format: currency of rows without one")
+		mapping  importer.Mapping
+	)
+	flag.StringVar(&mapping.Date, "date-column", "", "generic format: date column")
+	flag.StringVar(&mapping.Description, "description-column", "", "generic format: description column")
+	flag.StringVar(&mapping.Category, "category-column", "", "generic format: category column")
+	flag.StringVar(&mapping.Amount, "amount-column", "", "generic format: amount column")
+	flag.StringVar(&mapping.Currency, "currency-column", "", "generic format: currency column")
+	flag.StringVar(&mapping.Payer, "payer-column", "", "generic format: payer column")
+	flag.StringVar(&mapping.Participants, "participants-column", "", "generic format: participants column")
+	flag.StringVar(&mapping.DateLayout, "date-layout", "", "generic format: Go time layout of the date column")
+	flag.Parse()
+
+	if *file == "" || *groupID == 0 || *userID == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := repository.NewDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	exchangeRates, err := currency.LoadStaticProvider(cfg.ExchangeRatesFile)
+	if err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err)
+	}
+
+	blobs, err := storage.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up attachment storage: %v", err)
+	}
+
+	in, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *file, err)
+	}
+	defer in.Close()
+
+	var records []importer.Record
+	switch importer.Format(*format) {
+	case importer.FormatSplitwise:
+		records, err = importer.ParseSplitwise(in)
+	case importer.FormatGeneric:
+		records, err = importer.ParseGeneric(in, mapping, *fallback)
+	default:
+		err = importer.ErrUnknownFormat
+	}
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", *file, err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	groupRepo := repository.NewGroupRepository(db)
+	expenseRepo := repository.NewExpenseRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	activityRepo := repository.NewActivityRepository(db)
+
+	activityService := service.NewActivityService(activityRepo, groupRepo, nil, slog.Default())
+	expenseService := service.NewExpenseService(expenseRepo, groupRepo, categoryRepo, blobs, exchangeRates, activityService, db)
+	importService := service.NewImportService(groupRepo, userRepo, paymentRepo, categoryRepo, expenseService, exchangeRates, activityService, db)
+
+	ctx := service.WithQuietActivities(auth.WithUserID(context.Background(), *userID))
+	report, err := importService.Import(ctx, *groupID, records, *dryRun)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	if err := out.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
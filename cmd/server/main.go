@@ -11,11 +11,18 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"expense-tracker/internal/auth"
 	"expense-tracker/internal/config"
+	"expense-tracker/internal/currency"
 	"expense-tracker/internal/handler"
 	"expense-tracker/internal/middleware"
+	"expense-tracker/internal/notify"
 	"expense-tracker/internal/repository"
+	"expense-tracker/internal/scheduler"
 	"expense-tracker/internal/service"
+	"expense-tracker/internal/storage"
+	"expense-tracker/internal/stream"
+	"expense-tracker/internal/webhook"
 )
 
 func main() {
@@ -31,19 +38,71 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	exchangeRates, err := currency.LoadStaticProvider(cfg.ExchangeRatesFile)
+	if err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err)
+	}
+
+	tokenManager := auth.NewTokenManager(cfg.JWTSecret, cfg.JWTTTL)
+
+	mailer, err := notify.Open(cfg, middleware.Logger)
+	if err != nil {
+		log.Fatalf("Failed to set up email notifications: %v", err)
+	}
+	outbox := notify.NewOutbox(mailer, middleware.Logger)
+
+	// Live group updates are fanned out in-process
+	streamHub := stream.NewHub()
+
+	blobs, err := storage.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up attachment storage: %v", err)
+	}
+
 	// 3. Initialize Repositories
+	userRepo := repository.NewUserRepository(db)
 	groupRepo := repository.NewGroupRepository(db)
 	expenseRepo := repository.NewExpenseRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	recurringRepo := repository.NewRecurringExpenseRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	activityRepo := repository.NewActivityRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
 
 	// 4. Initialize Services
-	groupService := service.NewGroupService(groupRepo)
-	expenseService := service.NewExpenseService(expenseRepo)
-	settlementService := service.NewSettlementService(expenseRepo)
+	authService := service.NewAuthService(userRepo, tokenManager)
+	settlementService := service.NewSettlementService(expenseRepo, paymentRepo, groupRepo)
+	webhookService := service.NewWebhookService(webhookRepo, groupRepo, webhook.NewClient(webhook.DefaultTimeout))
+	streamService := service.NewStreamService(streamHub, groupRepo, settlementService, tokenManager, middleware.Logger)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, groupRepo, expenseRepo, settlementService, mailer, outbox, middleware.Logger)
+	activityService := service.NewActivityService(activityRepo, groupRepo, webhookService, middleware.Logger, streamService, notificationService)
+	expenseService := service.NewExpenseService(expenseRepo, groupRepo, categoryRepo, blobs, exchangeRates, activityService, db)
+	groupService := service.NewGroupService(groupRepo, userRepo, settlementService, activityService, db)
+	paymentService := service.NewPaymentService(paymentRepo, groupRepo, activityService, db)
+	recurringService := service.NewRecurringExpenseService(recurringRepo, groupRepo, categoryRepo, expenseService, exchangeRates, db)
+	categoryService := service.NewCategoryService(categoryRepo, groupRepo, expenseRepo)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, groupRepo)
+	attachmentService := service.NewAttachmentService(attachmentRepo, expenseRepo, groupRepo, blobs, cfg.MaxAttachmentSize)
+	importService := service.NewImportService(groupRepo, userRepo, paymentRepo, categoryRepo, expenseService, exchangeRates, activityService, db)
 
 	// 5. Initialize Handlers
+	authHandler := handler.NewAuthHandler(authService)
 	groupHandler := handler.NewGroupHandler(groupService)
 	expenseHandler := handler.NewExpenseHandler(expenseService)
 	settlementHandler := handler.NewSettlementHandler(settlementService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	recurringHandler := handler.NewRecurringExpenseHandler(recurringService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	importHandler := handler.NewImportHandler(importService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService, cfg.MaxAttachmentSize)
+	activityHandler := handler.NewActivityHandler(activityService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	streamHandler := handler.NewStreamHandler(streamService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
 
 	// 6. Setup Gin Router
 	gin.SetMode(gin.ReleaseMode) // Use release mode in production
@@ -56,12 +115,55 @@ func main() {
 	router.Use(middleware.ErrorHandler())
 
 	// 7. Register Routes
-	v1 := router.Group("/v1")
+	// Registration and login are the only public endpoints; every /v1 route requires a token
+	router.POST("/auth/register", authHandler.Register)
+	router.POST("/auth/login", authHandler.Login)
+
+	// The stream also takes a stream token in the query, for browsers' EventSource
+	router.GET("/v1/groups/:id/stream", middleware.RequireStreamAuth(tokenManager), streamHandler.StreamGroup)
+
+	v1 := router.Group("/v1", middleware.RequireAuth(tokenManager))
 	{
 		v1.POST("/groups", groupHandler.CreateGroup)
+		v1.GET("/groups/:id", groupHandler.GetGroup)
+		v1.PATCH("/groups/:id", groupHandler.RenameGroup)
+		v1.GET("/groups/:id/members", groupHandler.GetMembers)
+		v1.POST("/groups/:id/members", groupHandler.AddMembers)
+		v1.DELETE("/groups/:id/members/:userId", groupHandler.RemoveMember)
+		v1.GET("/groups/:id/expenses", expenseHandler.ListExpenses)
 		v1.POST("/groups/:id/expenses", expenseHandler.AddExpense)
+		v1.PUT("/groups/:id/expenses/:expenseId", expenseHandler.UpdateExpense)
+		v1.DELETE("/groups/:id/expenses/:expenseId", expenseHandler.DeleteExpense)
+		v1.GET("/groups/:id/expenses/:expenseId/history", expenseHandler.GetExpenseHistory)
+		v1.GET("/groups/:id/expenses/:expenseId/attachments", attachmentHandler.GetAttachments)
+		v1.POST("/groups/:id/expenses/:expenseId/attachments", attachmentHandler.UploadAttachment)
+		v1.GET("/groups/:id/expenses/:expenseId/attachments/:attachmentId", attachmentHandler.DownloadAttachment)
+		v1.DELETE("/groups/:id/expenses/:expenseId/attachments/:attachmentId", attachmentHandler.DeleteAttachment)
+		v1.GET("/groups/:id/export.csv", expenseHandler.ExportExpenses)
+		v1.POST("/groups/:id/import", importHandler.Import)
 		v1.GET("/groups/:id/balances", settlementHandler.GetBalances)
 		v1.GET("/groups/:id/settlements", settlementHandler.GetSettlements)
+		v1.GET("/groups/:id/debts", settlementHandler.GetDebts)
+		v1.POST("/groups/:id/payments", paymentHandler.RecordPayment)
+		v1.GET("/users/:id/settlements", settlementHandler.GetUserSettlements)
+		v1.POST("/groups/:id/recurring-expenses", recurringHandler.CreateRecurringExpense)
+		v1.GET("/groups/:id/recurring-expenses", recurringHandler.GetRecurringExpenses)
+		v1.DELETE("/groups/:id/recurring-expenses/:recurringId", recurringHandler.DeleteRecurringExpense)
+		v1.GET("/groups/:id/categories", categoryHandler.GetCategories)
+		v1.POST("/groups/:id/categories", categoryHandler.CreateCategory)
+		v1.GET("/groups/:id/reports/categories", categoryHandler.GetCategoryReport)
+		v1.GET("/groups/:id/analytics", analyticsHandler.GetGroupAnalytics)
+		v1.GET("/users/:id/analytics", analyticsHandler.GetUserAnalytics)
+		v1.GET("/groups/:id/activity", activityHandler.GetGroupFeed)
+		v1.POST("/groups/:id/activity/read", activityHandler.MarkRead)
+		v1.GET("/users/:id/activity", activityHandler.GetUserFeed)
+		v1.POST("/groups/:id/webhooks", webhookHandler.CreateWebhook)
+		v1.GET("/groups/:id/webhooks", webhookHandler.GetWebhooks)
+		v1.DELETE("/groups/:id/webhooks/:webhookId", webhookHandler.DeleteWebhook)
+		v1.GET("/groups/:id/webhooks/:webhookId/deliveries", webhookHandler.GetDeliveries)
+		v1.POST("/groups/:id/stream/token", streamHandler.IssueStreamToken)
+		v1.GET("/users/:id/notifications", notificationHandler.GetPreferences)
+		v1.PUT("/users/:id/notifications", notificationHandler.UpdatePreferences)
 	}
 
 	// Simple healthcheck
@@ -74,6 +176,17 @@ func main() {
 		Addr:    ":" + cfg.ServerPort,
 		Handler: router,
 	}
+	// Shutdown waits for open requests, so end the live streams as soon as it starts
+	srv.RegisterOnShutdown(streamHub.Close)
+
+	// Start background workers
+	recurringWorker := scheduler.NewRecurringWorker(recurringService, cfg.RecurringPollInterval, middleware.Logger)
+	recurringWorker.Start()
+	webhookWorker := scheduler.NewWebhookWorker(webhookService, cfg.WebhookPollInterval, middleware.Logger)
+	webhookWorker.Start()
+	digestWorker := scheduler.NewDigestWorker(notificationService, cfg.DigestPollInterval, middleware.Logger)
+	digestWorker.Start()
+	outbox.Start()
 
 	go func() {
 		log.Printf("Starting Server on port %s", cfg.ServerPort)
@@ -95,5 +208,14 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Stop the schedulers after in-flight requests have drained
+	recurringWorker.Stop()
+	webhookWorker.Stop()
+	digestWorker.Stop()
+	// Send the emails that are still queued, but don't hold up shutdown on a slow mail server
+	mailCtx, mailCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer mailCancel()
+	outbox.Stop(mailCtx)
+
 	log.Println("Server exiting")
 }
@@ -10,15 +10,30 @@ const api = axios.create({
 export const groupService = {
     getGroups: () => api.get('/groups').then(res => res.data),
     createGroup: (data) => api.post('/groups', data).then(res => res.data),
+    // User IDs of the group's members; only members can pay for or share an expense
+    getMembers: (groupId) => api.get(`/groups/${groupId}/members`).then(res => res.data),
+    addMember: (groupId, userId) => api.post(`/groups/${groupId}/members`, { user_id: userId }),
     addExpense: (groupId, data) => api.post(`/groups/${groupId}/expenses`, data).then(res => res.data),
     getBalances: (groupId) => api.get(`/groups/${groupId}/balances`).then(res => res.data),
     getSettlements: (groupId) => api.get(`/groups/${groupId}/settlements`).then(res => res.data),
+    // Server-Sent Events; the browser reconnects on its own and gets the current balances again
+    streamGroup: (groupId) => new EventSource(`${api.defaults.baseURL}/groups/${groupId}/stream`),
 };
 
 export const userService = {
     getUsers: () => api.get('/users').then(res => res.data),
     createUser: (data) => api.post('/users', data).then(res => res.data),
-    getActivities: () => api.get('/activities').then(res => res.data),
+    // Newest first. Follows next_cursor to the end of the feed, so totals built from it are complete
+    getActivities: async (params = {}) => {
+        const activities = [];
+        let cursor = params.cursor;
+        do {
+            const res = await api.get('/activities', { params: { limit: 100, ...params, cursor } });
+            activities.push(...res.data.activities);
+            cursor = res.data.next_cursor;
+        } while (cursor);
+        return activities;
+    },
 };
 
 export default api;
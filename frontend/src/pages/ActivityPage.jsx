@@ -40,7 +40,7 @@ const ActivityPage = () => {
 
       // Aggregate Daily Spend
       const daysMap = {};
-      sortedActs.forEach(act => {
+      sortedActs.filter(act => act.type === 'expense_added').forEach(act => {
         const d = new Date(act.created_at).toLocaleDateString('en-US', { month: 'short', day: 'numeric' });
         daysMap[d] = (daysMap[d] || 0) + act.amount;
       });
@@ -57,6 +57,23 @@ const ActivityPage = () => {
     }
   };
 
+  const userName = (id) => users[id] || `User ${id}`;
+
+  const describe = (act) => {
+    switch (act.type) {
+      case 'expense_added':
+        return <><strong>{userName(act.actor_id)}</strong> paid <strong>{formatCurrency(act.amount)}</strong> for <strong>{act.description}</strong></>;
+      case 'payment_recorded':
+        return <><strong>{userName(act.actor_id)}</strong> paid <strong>{userName(act.user_id)}</strong> <strong>{formatCurrency(act.amount)}</strong></>;
+      case 'member_joined':
+        return <><strong>{userName(act.user_id)}</strong> joined the group</>;
+      case 'member_left':
+        return <><strong>{userName(act.user_id)}</strong> left the group</>;
+      default:
+        return <><strong>{userName(act.actor_id)}</strong> updated the group</>;
+    }
+  };
+
   const formatDate = (dateString) => {
     const d = new Date(dateString);
     return d.toLocaleDateString() + ' ' + d.toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
@@ -138,7 +155,7 @@ const ActivityPage = () => {
                 </div>
                 <div className="flex-1">
                   <p className="text-sm text-gray-900">
-                    <strong>{users[act.payer_id] || `User ${act.payer_id}`}</strong> paid <strong>{formatCurrency(act.amount)}</strong> for <strong>{act.description}</strong>
+                    {describe(act)}
                   </p>
                   <p className="text-xs text-gray-500 mt-1">
                     in "{groups[act.group_id] || `Group ${act.group_id}`}"  •  {formatDate(act.created_at)}
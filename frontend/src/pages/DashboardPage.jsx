@@ -17,7 +17,8 @@ const DashboardPage = () => {
     const [totalSpent, setTotalSpent] = useState(0);
     const [loading, setLoading] = useState(true);
     const [isModalOpen, setIsModalOpen] = useState(false);
-    const [newGroup, setNewGroup] = useState({ name: '', description: '' });
+    const [newGroup, setNewGroup] = useState({ name: '', description: '', member_ids: [] });
+    const [users, setUsers] = useState([]);
 
     useEffect(() => {
         fetchGroups();
@@ -25,15 +26,17 @@ const DashboardPage = () => {
 
     const fetchGroups = async () => {
         try {
-            const [grpData, actData] = await Promise.all([
+            const [grpData, actData, userData] = await Promise.all([
                 groupService.getGroups(),
-                userService.getActivities()
+                userService.getActivities(),
+                userService.getUsers()
             ]);
             setGroups(grpData);
+            setUsers(userData || []);
 
             let total = 0;
             const groupTotals = {};
-            (actData || []).forEach(act => {
+            (actData || []).filter(act => act.type === 'expense_added').forEach(act => {
                 groupTotals[act.group_id] = (groupTotals[act.group_id] || 0) + act.amount;
                 total += act.amount;
             });
@@ -57,13 +60,20 @@ const DashboardPage = () => {
         try {
             await groupService.createGroup(newGroup);
             setIsModalOpen(false);
-            setNewGroup({ name: '', description: '' });
+            setNewGroup({ name: '', description: '', member_ids: [] });
             fetchGroups();
         } catch (error) {
             console.error("Error creating group:", error);
         }
     };
 
+    const toggleMember = (uid) => {
+        const memberIds = newGroup.member_ids.includes(uid)
+            ? newGroup.member_ids.filter(id => id !== uid)
+            : [...newGroup.member_ids, uid];
+        setNewGroup({ ...newGroup, member_ids: memberIds });
+    };
+
     return (
         <div className="space-y-6">
             <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-4">
@@ -162,6 +172,25 @@ const DashboardPage = () => {
                         value={newGroup.description}
                         onChange={e => setNewGroup({ ...newGroup, description: e.target.value })}
                     />
+                    <div className="space-y-1">
+                        <label className="text-sm font-medium text-gray-700">Members</label>
+                        {users.length === 0 ? (
+                            <p className="text-sm text-gray-500">No users yet.</p>
+                        ) : (
+                            <div className="space-y-2 max-h-40 overflow-y-auto border border-gray-100 rounded-lg p-3 bg-gray-50">
+                                {users.map(u => (
+                                    <label key={u.id} className="flex items-center gap-2 text-sm text-gray-700">
+                                        <input
+                                            type="checkbox"
+                                            checked={newGroup.member_ids.includes(u.id)}
+                                            onChange={() => toggleMember(u.id)}
+                                        />
+                                        {u.name}
+                                    </label>
+                                ))}
+                            </div>
+                        )}
+                    </div>
                     <div className="pt-4 flex justify-end gap-3">
                         <Button variant="secondary" onClick={() => setIsModalOpen(false)}>Cancel</Button>
                         <Button type="submit">Create</Button>
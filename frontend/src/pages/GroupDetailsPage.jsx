@@ -14,6 +14,8 @@ const GroupDetailsPage = () => {
     const [balances, setBalances] = useState([]);
     const [settlements, setSettlements] = useState([]);
     const [allUsers, setAllUsers] = useState([]);
+    const [memberIds, setMemberIds] = useState([]);
+    const [newMemberId, setNewMemberId] = useState('');
     const [loading, setLoading] = useState(true);
 
     // Expense Modal State
@@ -32,6 +34,19 @@ const GroupDetailsPage = () => {
         fetchGroupData();
     }, [id]);
 
+    // Keep balances live while others add expenses and payments
+    useEffect(() => {
+        const source = groupService.streamGroup(id);
+        source.addEventListener('balances', (e) => {
+            setBalances(JSON.parse(e.data) || []);
+            // Settlements are derived from the balances
+            groupService.getSettlements(id)
+                .then(setSettlements)
+                .catch(error => console.error("Failed to refresh settlements:", error));
+        });
+        return () => source.close();
+    }, [id]);
+
     const fetchGroupData = async () => {
         try {
             setLoading(true);
@@ -41,15 +56,17 @@ const GroupDetailsPage = () => {
             setGroup(currentGroup);
 
             // Fetch balances and settlements concurrently
-            const [bals, setts, users] = await Promise.all([
+            const [bals, setts, users, members] = await Promise.all([
                 groupService.getBalances(id),
                 groupService.getSettlements(id),
-                userService.getUsers()
+                userService.getUsers(),
+                groupService.getMembers(id)
             ]);
 
             setBalances(bals);
             setSettlements(setts);
             setAllUsers(users || []);
+            setMemberIds(members || []);
         } catch (error) {
             console.error("Failed to fetch group data:", error);
         } finally {
@@ -67,8 +84,21 @@ const GroupDetailsPage = () => {
         return name ? name.charAt(0).toUpperCase() : 'U';
     };
 
-    // Build a unique users list for this group from balances
-    const groupUsers = balances.map(b => b.user_id);
+    // Only members can pay for or share an expense
+    const members = allUsers.filter(u => memberIds.includes(u.id));
+    const nonMembers = allUsers.filter(u => !memberIds.includes(u.id));
+
+    const handleAddMember = async (e) => {
+        e.preventDefault();
+        if (!newMemberId) return;
+        try {
+            await groupService.addMember(id, parseInt(newMemberId));
+            setNewMemberId('');
+            fetchGroupData();
+        } catch (error) {
+            console.error("Failed to add member:", error);
+        }
+    };
 
     const handleAddExpense = async (e) => {
         e.preventDefault();
@@ -78,23 +108,11 @@ const GroupDetailsPage = () => {
 
         let splits = [];
         if (expenseForm.splitType === 'EQUAL') {
-            const payerId = parseInt(expenseForm.payer_id);
-            let userIds = [payerId];
-
-            // If they are the first expense, just grab another user from allUsers to split with
-            if (groupUsers.length === 0) {
-                const other = allUsers.find(u => u.id !== payerId);
-                if (other) userIds.push(other.id);
-            } else {
-                userIds = Array.from(new Set([...groupUsers, payerId]));
-            }
+            // Everyone in the group shares the expense
+            const baseSplit = Math.floor(amountInCents / memberIds.length);
+            let remainder = amountInCents % memberIds.length;
 
-            const actualUsers = userIds.length > 0 ? userIds : [payerId];
-
-            const baseSplit = Math.floor(amountInCents / actualUsers.length);
-            let remainder = amountInCents % actualUsers.length;
-
-            splits = actualUsers.map(uid => {
+            splits = memberIds.map(uid => {
                 let owed = baseSplit;
                 if (remainder > 0) {
                     owed += 1;
@@ -155,7 +173,7 @@ const GroupDetailsPage = () => {
                         <p className="text-gray-500 text-sm mt-1">{group.description || 'No description assigned.'}</p>
                     </div>
                 </div>
-                <Button onClick={() => setIsExpenseOpen(true)} className="flex items-center justify-center gap-2">
+                <Button onClick={() => setIsExpenseOpen(true)} disabled={members.length === 0} className="flex items-center justify-center gap-2">
                     <Receipt size={18} />
                     Add Expense
                 </Button>
@@ -165,6 +183,39 @@ const GroupDetailsPage = () => {
 
                 {/* Left Column - Balances & Group Info */}
                 <div className="space-y-6 lg:col-span-1">
+                    <Card>
+                        <h2 className="text-lg font-semibold text-gray-900 mb-4 flex items-center gap-2">
+                            <Users size={20} className="text-brand-500" />
+                            Members
+                        </h2>
+                        {members.length === 0 ? (
+                            <p className="text-sm text-gray-500 mb-4">Add members before adding expenses.</p>
+                        ) : (
+                            <div className="flex flex-wrap gap-2 mb-4">
+                                {members.map(u => (
+                                    <span key={u.id} className="px-3 py-1 rounded-full bg-gray-50 border border-gray-100 text-sm text-gray-700">{u.name}</span>
+                                ))}
+                            </div>
+                        )}
+                        {nonMembers.length > 0 && (
+                            <form onSubmit={handleAddMember} className="flex gap-2">
+                                <select
+                                    value={newMemberId}
+                                    onChange={e => setNewMemberId(e.target.value)}
+                                    className="flex-1 px-3 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-brand-500 focus:border-transparent bg-white text-sm"
+                                >
+                                    <option value="" disabled>Select User</option>
+                                    {nonMembers.map(u => (
+                                        <option key={u.id} value={u.id}>{u.name}</option>
+                                    ))}
+                                </select>
+                                <Button type="submit" variant="secondary" className="flex items-center gap-1">
+                                    <Plus size={16} />
+                                    Add
+                                </Button>
+                            </form>
+                        )}
+                    </Card>
                     <Card>
                         <h2 className="text-lg font-semibold text-gray-900 mb-4 flex items-center gap-2">
                             <Users size={20} className="text-brand-500" />
@@ -259,7 +310,7 @@ const GroupDetailsPage = () => {
                                 className="w-full px-3 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-brand-500 focus:border-transparent bg-white h-[42px]"
                             >
                                 <option value="" disabled>Select User</option>
-                                {allUsers.map((user) => (
+                                {members.map((user) => (
                                     <option key={user.id} value={user.id}>
                                         {user.name}
                                     </option>
@@ -282,7 +333,7 @@ const GroupDetailsPage = () => {
                         {expenseForm.splitType === 'CUSTOM' && (
                             <div className="space-y-3 mt-4 border border-gray-100 rounded-lg p-3 bg-gray-50">
                                 <p className="text-xs font-semibold text-gray-500 uppercase tracking-wider mb-2">Assign Exact Amounts</p>
-                                {allUsers.map((u) => (
+                                {members.map((u) => (
                                     <div key={u.id} className="flex items-center justify-between gap-3">
                                         <label className="text-sm font-medium text-gray-700 truncate flex-1">{u.name}</label>
                                         <div className="flex bg-white items-center border border-gray-300 rounded-lg px-2 w-32 focus-within:ring-2 focus-within:ring-brand-500 focus-within:border-transparent">
@@ -0,0 +1,154 @@
+package algorithm
+
+import (
+	"time"
+
+	"expense-tracker/internal/model"
+)
+
+// Strategy selects which settlement algorithm is used.
+type Strategy string
+
+const (
+	StrategyGreedy  Strategy = "greedy"
+	StrategyOptimal Strategy = "optimal"
+)
+
+// MaxOptimalParticipants caps the number of non-zero balances the exact solver accepts.
+// The DP table has 2^N entries, so 20 people means about a million states.
+const MaxOptimalParticipants = 20
+
+// OptimalTimeBudget bounds how long the exact solver may run before falling back to greedy.
+var OptimalTimeBudget = 250 * time.Millisecond
+
+// Settle computes settlements with the requested strategy. Unknown strategies use greedy.
+func Settle(balances []model.UserBalance, strategy Strategy) []model.Settlement {
+	if strategy == StrategyOptimal {
+		if settlements, ok := OptimalSettlements(balances, OptimalTimeBudget); ok {
+			return settlements
+		}
+	}
+	return CalculateSettlements(balances)
+}
+
+// OptimalSettlements finds a settlement with the true minimum number of transfers.
+//
+// Any group of people whose balances sum to zero can be settled among themselves with
+// (size - 1) transfers, so the minimum for N people is N minus the maximum number of
+// disjoint zero-sum subsets the balances can be partitioned into. That maximum is found with
+// a bitmask DP over all subsets:
+//
+//	dp[mask] = max over i in mask of dp[mask without i] + (1 if sum(mask) == 0)
+//
+// Each zero-sum subset is then settled with the greedy matcher, which needs at most
+// (size - 1) transfers for it. Time Complexity: O(2^N * N).
+//
+// It reports false when there are more than MaxOptimalParticipants non-zero balances, when
+// the budget runs out, or if the result would not clear every balance; callers should then
+// fall back to CalculateSettlements.
+func OptimalSettlements(balances []model.UserBalance, budget time.Duration) ([]model.Settlement, bool) {
+	var people []model.UserBalance
+	for _, b := range balances {
+		if b.Balance != 0 {
+			people = append(people, b)
+		}
+	}
+
+	n := len(people)
+	if n == 0 {
+		return nil, true
+	}
+	if n > MaxOptimalParticipants {
+		return nil, false
+	}
+
+	deadline := time.Now().Add(budget)
+	full := 1<<n - 1
+
+	// sums[mask] is the total balance of the people in mask, built from the mask without its lowest bit
+	sums := make([]int64, full+1)
+	dp := make([]uint8, full+1)
+	for mask := 1; mask <= full; mask++ {
+		// Checking the clock on every state would dominate the run time
+		if mask&0xFFFF == 0 && time.Now().After(deadline) {
+			return nil, false
+		}
+
+		low := mask & -mask
+		sums[mask] = sums[mask^low] + people[bitIndex(low)].Balance
+
+		var best uint8
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			bit := rest & -rest
+			if dp[mask^bit] > best {
+				best = dp[mask^bit]
+			}
+		}
+		if sums[mask] == 0 {
+			best++
+		}
+		dp[mask] = best
+	}
+
+	// Walk back from the full set, peeling off one person at a time along an optimal path.
+	// Every time the remaining set sums to zero, the people peeled off since the previous
+	// zero-sum point form one independent subset.
+	var settlements []model.Settlement
+	var subset []model.UserBalance
+	for mask := full; mask != 0; {
+		gain := uint8(0)
+		if sums[mask] == 0 {
+			gain = 1
+		}
+
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			bit := rest & -rest
+			if dp[mask^bit]+gain == dp[mask] {
+				subset = append(subset, people[bitIndex(bit)])
+				mask ^= bit
+				break
+			}
+		}
+
+		if sums[mask] == 0 {
+			settlements = append(settlements, CalculateSettlements(subset)...)
+			subset = nil
+		}
+	}
+
+	if len(settlements) != n-int(dp[full]) || !SettlesAll(balances, settlements) {
+		return nil, false
+	}
+	return settlements, true
+}
+
+// SettlesAll reports whether applying the settlements brings every balance to exactly zero.
+func SettlesAll(balances []model.UserBalance, settlements []model.Settlement) bool {
+	remaining := make(map[uint]int64, len(balances))
+	for _, b := range balances {
+		remaining[b.UserID] += b.Balance
+	}
+	for _, s := range settlements {
+		if s.Amount <= 0 {
+			return false
+		}
+		remaining[s.FromUserID] += s.Amount
+		remaining[s.ToUserID] -= s.Amount
+	}
+	for _, balance := range remaining {
+		if balance != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bitIndex returns the position of the single set bit in bit.
+func bitIndex(bit int) int {
+	i := 0
+	for bit > 1 {
+		bit >>= 1
+		i++
+	}
+	return i
+}
@@ -0,0 +1,128 @@
+package algorithm
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/model"
+)
+
+// randomBalances returns n balances that add up to zero. Small values make zero-sum subsets,
+// and with them the interesting cases for the optimal solver, common.
+func randomBalances(r *rand.Rand, n int, maxAbs int64) []model.UserBalance {
+	balances := make([]model.UserBalance, n)
+	var sum int64
+	for i := 0; i < n-1; i++ {
+		balances[i] = model.UserBalance{UserID: uint(i + 1), Balance: r.Int63n(2*maxAbs+1) - maxAbs}
+		sum += balances[i].Balance
+	}
+	balances[n-1] = model.UserBalance{UserID: uint(n), Balance: -sum}
+	return balances
+}
+
+// maxZeroSumSubsets partitions the non-zero balances by brute force, independently of the
+// DP in OptimalSettlements, and returns how many people there are and the largest number of
+// zero-sum subsets they split into.
+func maxZeroSumSubsets(balances []model.UserBalance) (int, int) {
+	var values []int64
+	for _, b := range balances {
+		if b.Balance != 0 {
+			values = append(values, b.Balance)
+		}
+	}
+
+	var best func(mask int) int
+	best = func(mask int) int {
+		if mask == 0 {
+			return 0
+		}
+		low := mask & -mask
+		result := 0
+		// Every subset that holds the lowest remaining person and sums to zero
+		for sub := mask; sub != 0; sub = (sub - 1) & mask {
+			if sub&low == 0 {
+				continue
+			}
+			var sum int64
+			for i := range values {
+				if sub&(1<<i) != 0 {
+					sum += values[i]
+				}
+			}
+			if sum == 0 {
+				result = max(result, 1+best(mask^sub))
+			}
+		}
+		return result
+	}
+	return len(values), best(1<<len(values) - 1)
+}
+
+func TestOptimalSettlementsSettleAll(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		balances := randomBalances(r, 2+r.Intn(11), 100)
+
+		settlements, ok := OptimalSettlements(balances, time.Second)
+		if !ok {
+			t.Fatalf("no result for %v", balances)
+		}
+		if !SettlesAll(balances, settlements) {
+			t.Fatalf("%v does not settle %v", settlements, balances)
+		}
+	}
+}
+
+func TestOptimalSettlementsMinimumTransfers(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 300; i++ {
+		balances := randomBalances(r, 2+r.Intn(7), 10)
+
+		settlements, ok := OptimalSettlements(balances, time.Second)
+		if !ok {
+			t.Fatalf("no result for %v", balances)
+		}
+		n, subsets := maxZeroSumSubsets(balances)
+		if want := n - subsets; len(settlements) != want {
+			t.Fatalf("%v took %d transfers, want %d: %v", balances, len(settlements), want, settlements)
+		}
+	}
+}
+
+func TestOptimalSettlementsNeverWorseThanGreedy(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 500; i++ {
+		balances := randomBalances(r, 2+r.Intn(11), 20)
+
+		optimal, ok := OptimalSettlements(balances, time.Second)
+		if !ok {
+			t.Fatalf("no result for %v", balances)
+		}
+		greedy := CalculateSettlements(balances)
+		if !SettlesAll(balances, greedy) {
+			t.Fatalf("greedy %v does not settle %v", greedy, balances)
+		}
+		if len(optimal) > len(greedy) {
+			t.Fatalf("%v took %d transfers, greedy took %d", balances, len(optimal), len(greedy))
+		}
+	}
+}
+
+func TestOptimalSettlementsTooManyParticipants(t *testing.T) {
+	n := MaxOptimalParticipants + 2
+	balances := make([]model.UserBalance, n)
+	var sum int64
+	for i := 0; i < n-1; i++ {
+		balances[i] = model.UserBalance{UserID: uint(i + 1), Balance: int64(i + 1)}
+		sum += balances[i].Balance
+	}
+	balances[n-1] = model.UserBalance{UserID: uint(n), Balance: -sum}
+
+	if _, ok := OptimalSettlements(balances, time.Second); ok {
+		t.Fatal("expected the solver to refuse more than MaxOptimalParticipants balances")
+	}
+	if settlements := Settle(balances, StrategyOptimal); !SettlesAll(balances, settlements) {
+		t.Fatalf("fallback %v does not settle %v", settlements, balances)
+	}
+}
@@ -0,0 +1,45 @@
+package algorithm
+
+import (
+	"sort"
+
+	"expense-tracker/internal/model"
+)
+
+// TraceSettlements replays settlements in the order the algorithm produced them and records the
+// outstanding balances right before and after each transfer. Balances are sorted by user ID and
+// include everyone from the input, so settled users show up with a zero balance.
+func TraceSettlements(balances []model.UserBalance, settlements []model.Settlement) []model.SettlementStep {
+	state := make(map[uint]int64, len(balances))
+	for _, b := range balances {
+		state[b.UserID] += b.Balance
+	}
+
+	steps := make([]model.SettlementStep, 0, len(settlements))
+	for i, st := range settlements {
+		before := snapshot(state)
+
+		// The debtor pays, so their negative balance moves up; the creditor's moves down
+		state[st.FromUserID] += st.Amount
+		state[st.ToUserID] -= st.Amount
+
+		steps = append(steps, model.SettlementStep{
+			Step:           i + 1,
+			Settlement:     st,
+			BalancesBefore: before,
+			BalancesAfter:  snapshot(state),
+		})
+	}
+	return steps
+}
+
+func snapshot(state map[uint]int64) []model.UserBalance {
+	balances := make([]model.UserBalance, 0, len(state))
+	for id, balance := range state {
+		balances = append(balances, model.UserBalance{UserID: id, Balance: balance})
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].UserID < balances[j].UserID
+	})
+	return balances
+}
@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type contextKey struct{}
+
+// WithUserID returns a copy of ctx carrying the authenticated caller's user ID.
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserIDFromContext returns the authenticated caller's user ID, if any.
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(contextKey{}).(uint)
+	return userID, ok && userID != 0
+}
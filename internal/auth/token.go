@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// StreamTokenTTL is how long a stream token can be used to open a stream.
+const StreamTokenTTL = time.Minute
+
+// TokenManager issues and verifies HS256-signed JWTs carrying the user ID as subject.
+// Access tokens have no audience; stream tokens are limited to one group's event stream.
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokenManager(secret string, ttl time.Duration) *TokenManager {
+	return &TokenManager{secret: []byte(secret), ttl: ttl}
+}
+
+// Issue creates a signed token for the given user that expires after the configured TTL.
+func (m *TokenManager) Issue(userID uint) (string, time.Time, error) {
+	return m.issue(userID, m.ttl, nil)
+}
+
+// IssueStreamToken creates a token that only opens the given group's event stream, for
+// clients such as a browser's EventSource that can't send an Authorization header.
+func (m *TokenManager) IssueStreamToken(userID uint, groupID uint) (string, time.Time, error) {
+	return m.issue(userID, StreamTokenTTL, jwt.ClaimStrings{streamAudience(groupID)})
+}
+
+// Parse verifies the token's signature and expiry and returns the user ID it was issued for.
+func (m *TokenManager) Parse(token string) (uint, error) {
+	return m.parse(token, "")
+}
+
+// ParseStreamToken verifies a stream token for the group and returns the user it was issued for.
+func (m *TokenManager) ParseStreamToken(token string, groupID uint) (uint, error) {
+	return m.parse(token, streamAudience(groupID))
+}
+
+func streamAudience(groupID uint) string {
+	return "stream:" + strconv.FormatUint(uint64(groupID), 10)
+}
+
+func (m *TokenManager) issue(userID uint, ttl time.Duration, audience jwt.ClaimStrings) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Audience:  audience,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// parse verifies a token meant for audience, where an empty audience means an access token.
+func (m *TokenManager) parse(token string, audience string) (uint, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	// A stream token must never pass for an access token, nor for another group's stream
+	var want jwt.ClaimStrings
+	if audience != "" {
+		want = jwt.ClaimStrings{audience}
+	}
+	if !slices.Equal(claims.Audience, want) {
+		return 0, ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil || userID == 0 {
+		return 0, ErrInvalidToken
+	}
+	return uint(userID), nil
+}
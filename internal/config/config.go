@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +18,41 @@ type AppConfig struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+
+	// ExchangeRatesFile points to a JSON rate table used for multi-currency expenses.
+	// When empty, only expenses in a group's own base currency are accepted.
+	ExchangeRatesFile string
+
+	// JWTSecret signs access tokens and must be set; JWTTTL is how long a token stays valid.
+	JWTSecret string
+	JWTTTL    time.Duration
+
+	// RecurringPollInterval is how often the scheduler looks for due recurring expenses.
+	RecurringPollInterval time.Duration
+
+	// WebhookPollInterval is how often queued webhook deliveries are sent or retried.
+	WebhookPollInterval time.Duration
+
+	// Email notifications go out through the SMTP server at SMTPHost; when it is empty they
+	// are only logged. SMTPUsername is optional. DigestPollInterval is how often the scheduler
+	// checks whether the weekly digest is due.
+	SMTPHost           string
+	SMTPPort           string
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPFrom           string
+	DigestPollInterval time.Duration
+
+	// AttachmentStore selects where receipt files are kept: "local" (in AttachmentDir) or
+	// "s3" (any S3-compatible service). MaxAttachmentSize is in bytes.
+	AttachmentStore   string
+	AttachmentDir     string
+	MaxAttachmentSize int64
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
 }
 
 // LoadConfig loads configuration from the environment, optionally reading from a .env file
@@ -29,6 +67,62 @@ func LoadConfig() (*AppConfig, error) {
 		DBUser:     getEnv("DB_USER", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", "postgres"),
 		DBName:     getEnv("DB_NAME", "expense_tracker"),
+
+		ExchangeRatesFile: getEnv("EXCHANGE_RATES_FILE", ""),
+
+		JWTSecret: getEnv("JWT_SECRET", ""),
+
+		AttachmentStore:   getEnv("ATTACHMENT_STORE", "local"),
+		AttachmentDir:     getEnv("ATTACHMENT_DIR", "data/attachments"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "Expense Tracker <no-reply@localhost>"),
+	}
+
+	if cfg.JWTSecret == "" {
+		return nil, errors.New("JWT_SECRET must be set")
+	}
+
+	ttl, err := time.ParseDuration(getEnv("JWT_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_TTL: %w", err)
+	}
+	cfg.JWTTTL = ttl
+
+	pollInterval, err := time.ParseDuration(getEnv("RECURRING_POLL_INTERVAL", "1m"))
+	if err != nil || pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid RECURRING_POLL_INTERVAL: %q", getEnv("RECURRING_POLL_INTERVAL", ""))
+	}
+	cfg.RecurringPollInterval = pollInterval
+
+	webhookInterval, err := time.ParseDuration(getEnv("WEBHOOK_POLL_INTERVAL", "10s"))
+	if err != nil || webhookInterval <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_POLL_INTERVAL: %q", getEnv("WEBHOOK_POLL_INTERVAL", ""))
+	}
+	cfg.WebhookPollInterval = webhookInterval
+
+	digestInterval, err := time.ParseDuration(getEnv("DIGEST_POLL_INTERVAL", "15m"))
+	if err != nil || digestInterval <= 0 {
+		return nil, fmt.Errorf("invalid DIGEST_POLL_INTERVAL: %q", getEnv("DIGEST_POLL_INTERVAL", ""))
+	}
+	cfg.DigestPollInterval = digestInterval
+
+	maxAttachmentSize, err := strconv.ParseInt(getEnv("MAX_ATTACHMENT_SIZE", "10485760"), 10, 64)
+	if err != nil || maxAttachmentSize <= 0 {
+		return nil, fmt.Errorf("invalid MAX_ATTACHMENT_SIZE: %q", getEnv("MAX_ATTACHMENT_SIZE", ""))
+	}
+	cfg.MaxAttachmentSize = maxAttachmentSize
+
+	if cfg.AttachmentStore != "local" && cfg.AttachmentStore != "s3" {
+		return nil, fmt.Errorf("invalid ATTACHMENT_STORE: %q, expected local or s3", cfg.AttachmentStore)
 	}
 
 	return cfg, nil
@@ -0,0 +1,113 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for groups and expenses that don't specify a currency.
+const DefaultCurrency = "USD"
+
+// zeroDecimalCurrencies lists ISO-4217 currencies whose minor unit is the major unit,
+// so an amount of 500 means 500 yen rather than 5.00.
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true,
+	"KMF": true, "KRW": true, "PYG": true, "RWF": true, "UGX": true, "UYI": true,
+	"VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// threeDecimalCurrencies lists ISO-4217 currencies with a thousandth minor unit.
+var threeDecimalCurrencies = map[string]bool{
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true, "OMR": true, "TND": true,
+}
+
+// Normalize upper-cases a currency code and falls back to DefaultCurrency when empty.
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// MinorUnits returns the number of decimal places used by the currency's minor unit.
+func MinorUnits(code string) int {
+	switch {
+	case zeroDecimalCurrencies[code]:
+		return 0
+	case threeDecimalCurrencies[code]:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// CodesWithMinorUnits lists, sorted, the currencies known to have a minor unit with the given
+// number of decimal places. Any currency not listed for 0 or 3 has 2, so that lists nothing.
+func CodesWithMinorUnits(digits int) []string {
+	var codes []string
+	for _, set := range []map[string]bool{zeroDecimalCurrencies, threeDecimalCurrencies} {
+		for code := range set {
+			if MinorUnits(code) == digits {
+				codes = append(codes, code)
+			}
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Convert turns an amount in the minor units of `from` into minor units of `to`,
+// where rate is the price of one major unit of `from` expressed in `to`.
+// The result is rounded half away from zero to the nearest minor unit.
+func Convert(amount int64, from, to string, rate float64) int64 {
+	if from == to {
+		return amount
+	}
+	scale := math.Pow10(MinorUnits(to) - MinorUnits(from))
+	return int64(math.Round(float64(amount) * rate * scale))
+}
+
+// FormatAmount renders an amount in minor units as a plain decimal string, e.g. 1234 USD as
+// "12.34" and 1234 JPY as "1234". It uses integer arithmetic, so it is exact.
+func FormatAmount(amount int64, code string) string {
+	digits := MinorUnits(Normalize(code))
+	if digits == 0 {
+		return strconv.FormatInt(amount, 10)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	scale := int64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, digits, amount%scale)
+}
+
+// ParseAmount is the inverse of FormatAmount: it reads a decimal string such as "12.34" or
+// "-0.5" into minor units of the currency. More decimals than the currency has is an error.
+func ParseAmount(s string, code string) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	digits := MinorUnits(Normalize(code))
+	if whole == "" && frac == "" || len(frac) > digits {
+		return 0, fmt.Errorf("invalid %s amount %q", Normalize(code), s)
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || strings.ContainsAny(whole+frac, "+-") {
+		return 0, fmt.Errorf("invalid %s amount %q", Normalize(code), s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
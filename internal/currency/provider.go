@@ -0,0 +1,73 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+var (
+	ErrUnsupportedCurrency = errors.New("no exchange rate available for currency")
+)
+
+// ExchangeRateProvider looks up how much one major unit of `from` is worth in `to`.
+type ExchangeRateProvider interface {
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// StaticProvider serves rates from a fixed table, so it works fully offline.
+// Every rate is expressed against a single pivot currency: Rates["EUR"] = 0.92
+// means one unit of Base buys 0.92 EUR.
+type StaticProvider struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// NewStaticProvider builds a provider from a pivot currency and its rate table.
+func NewStaticProvider(base string, rates map[string]float64) *StaticProvider {
+	p := &StaticProvider{Base: Normalize(base), Rates: make(map[string]float64, len(rates))}
+	for code, rate := range rates {
+		p.Rates[Normalize(code)] = rate
+	}
+	p.Rates[p.Base] = 1
+	return p
+}
+
+// LoadStaticProvider reads a rate table from a JSON file shaped like
+// {"base": "USD", "rates": {"EUR": 0.92, "JPY": 151.3}}.
+// An empty path yields a provider that can only convert a currency into itself.
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	if path == "" {
+		return NewStaticProvider(DefaultCurrency, nil), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file StaticProvider
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	return NewStaticProvider(file.Base, file.Rates), nil
+}
+
+func (p *StaticProvider) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, ok := p.Rates[from]
+	if !ok || fromRate <= 0 {
+		return 0, ErrUnsupportedCurrency
+	}
+	toRate, ok := p.Rates[to]
+	if !ok || toRate <= 0 {
+		return 0, ErrUnsupportedCurrency
+	}
+
+	return toRate / fromRate, nil
+}
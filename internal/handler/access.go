@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/service"
+)
+
+// respondAccessError writes the response for authentication, group membership and missing
+// group failures returned by the service layer, and reports whether it handled the error.
+func respondAccessError(c *gin.Context, err error) bool {
+	switch err {
+	case service.ErrUnauthenticated:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case service.ErrNotGroupMember, service.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.ErrParticipantNotMember:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrGroupNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
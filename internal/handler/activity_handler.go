@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/service"
+)
+
+type ActivityHandler struct {
+	activityService service.ActivityService
+}
+
+func NewActivityHandler(activityService service.ActivityService) *ActivityHandler {
+	return &ActivityHandler{activityService: activityService}
+}
+
+// ActivityFeedQuery holds the query parameters of the activity feeds.
+type ActivityFeedQuery struct {
+	Cursor string `form:"cursor"` // next_cursor of the previous page
+	Limit  int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+// MarkReadRequest marks a group's feed as read up to an activity; without one, up to the newest.
+type MarkReadRequest struct {
+	ActivityID uint `json:"activity_id"`
+}
+
+// GetGroupFeed handles GET /groups/{id}/activity
+func (h *ActivityHandler) GetGroupFeed(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req ActivityFeedQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feed, err := h.activityService.GetGroupFeed(c.Request.Context(), uint(groupID), req.Cursor, req.Limit)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// GetUserFeed handles GET /users/{id}/activity
+func (h *ActivityHandler) GetUserFeed(c *gin.Context) {
+	userIDParam := c.Param("id")
+	userID, err := strconv.ParseUint(userIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req ActivityFeedQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feed, err := h.activityService.GetUserFeed(c.Request.Context(), uint(userID), req.Cursor, req.Limit)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// MarkRead handles POST /groups/{id}/activity/read
+func (h *ActivityHandler) MarkRead(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	// The body is optional
+	var req MarkReadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.activityService.MarkRead(c.Request.Context(), uint(groupID), req.ActivityID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ActivityHandler) respondError(c *gin.Context, err error) {
+	if respondAccessError(c, err) {
+		return
+	}
+	if err == repository.ErrInvalidCursor || err == service.ErrInvalidActivity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load activity"})
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/service"
+)
+
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// AnalyticsQuery holds the query parameters of the analytics endpoints.
+// Dates are RFC 3339; "to" is exclusive. The interval defaults to month.
+type AnalyticsQuery struct {
+	Interval string     `form:"interval" binding:"omitempty,oneof=day week month"`
+	From     *time.Time `form:"from"`
+	To       *time.Time `form:"to"`
+}
+
+func (q AnalyticsQuery) interval() model.AnalyticsInterval {
+	if q.Interval == "" {
+		return model.IntervalMonth
+	}
+	return model.AnalyticsInterval(q.Interval)
+}
+
+// GetGroupAnalytics handles GET /groups/{id}/analytics
+func (h *AnalyticsHandler) GetGroupAnalytics(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req AnalyticsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	analytics, err := h.analyticsService.GetGroupAnalytics(c.Request.Context(), uint(groupID), req.interval(), req.From, req.To)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// GetUserAnalytics handles GET /users/{id}/analytics
+func (h *AnalyticsHandler) GetUserAnalytics(c *gin.Context) {
+	userIDParam := c.Param("id")
+	userID, err := strconv.ParseUint(userIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req AnalyticsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	analytics, err := h.analyticsService.GetUserAnalytics(c.Request.Context(), uint(userID), req.interval(), req.From, req.To)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+func (h *AnalyticsHandler) respondError(c *gin.Context, err error) {
+	if respondAccessError(c, err) {
+		return
+	}
+	if err == service.ErrInvalidInterval || err == service.ErrInvalidRange || err == service.ErrRangeTooLarge {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics"})
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/service"
+)
+
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+	maxSize           int64
+}
+
+func NewAttachmentHandler(attachmentService service.AttachmentService, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService, maxSize: maxSize}
+}
+
+// multipartOverhead leaves room for the multipart boundaries and headers around the file.
+const multipartOverhead = 1 << 20
+
+// UploadAttachment handles POST /groups/{id}/expenses/{expenseId}/attachments with the
+// file in the "file" field of a multipart form
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	groupID, expenseID, ok := parseExpensePath(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required in the \"file\" form field and must not exceed the maximum size"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.UploadAttachment(c.Request.Context(), groupID, expenseID, header.Filename, header.Size, file)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		switch err {
+		case service.ErrExpenseNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case service.ErrAttachmentTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case service.ErrUnsupportedMediaType:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case service.ErrEmptyAttachment:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachments handles GET /groups/{id}/expenses/{expenseId}/attachments
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	groupID, expenseID, ok := parseExpensePath(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentService.GetAttachments(c.Request.Context(), groupID, expenseID)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrExpenseNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment handles GET /groups/{id}/expenses/{expenseId}/attachments/{attachmentId}
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	groupID, expenseID, ok := parseExpensePath(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	attachment, content, err := h.attachmentService.OpenAttachment(c.Request.Context(), groupID, expenseID, uint(attachmentID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrExpenseNotFound || err == service.ErrAttachmentNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download attachment"})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment handles DELETE /groups/{id}/expenses/{expenseId}/attachments/{attachmentId}
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	groupID, expenseID, ok := parseExpensePath(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(c.Request.Context(), groupID, expenseID, uint(attachmentID)); err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrExpenseNotFound || err == service.ErrAttachmentNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseExpensePath reads the group and expense IDs of an expense-scoped route,
+// responding with 400 when either is malformed.
+func parseExpensePath(c *gin.Context) (uint, uint, bool) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return 0, 0, false
+	}
+	expenseID, err := strconv.ParseUint(c.Param("expenseId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return 0, 0, false
+	}
+	return uint(groupID), uint(expenseID), true
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/service"
+)
+
+type CategoryHandler struct {
+	categoryService service.CategoryService
+}
+
+func NewCategoryHandler(categoryService service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
+
+type CreateCategoryRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+// CategoryReportQuery holds the query parameters of GET /groups/{id}/reports/categories.
+// Dates are RFC 3339; "to" is exclusive.
+type CategoryReportQuery struct {
+	From *time.Time `form:"from"`
+	To   *time.Time `form:"to"`
+}
+
+// GetCategories handles GET /groups/{id}/categories
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	categories, err := h.categoryService.GetCategories(c.Request.Context(), uint(groupID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// CreateCategory handles POST /groups/{id}/categories
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.CreateCategory(c.Request.Context(), uint(groupID), req.Name)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrInvalidCategory {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrCategoryExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// GetCategoryReport handles GET /groups/{id}/reports/categories
+func (h *CategoryHandler) GetCategoryReport(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req CategoryReportQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.categoryService.GetCategoryReport(c.Request.Context(), uint(groupID), req.From, req.To)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrInvalidRange {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build category report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
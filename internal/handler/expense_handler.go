@@ -1,12 +1,17 @@
 package handler
 
 import (
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"expense-tracker/internal/currency"
 	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
 	"expense-tracker/internal/service"
 )
 
@@ -18,14 +23,112 @@ func NewExpenseHandler(expenseService service.ExpenseService) *ExpenseHandler {
 	return &ExpenseHandler{expenseService: expenseService}
 }
 
+// SplitRequest is one participant of an expense. Only the field matching the
+// expense's split_type is read; for "equal" just the user_id is needed.
+type SplitRequest struct {
+	UserID     uint    `json:"user_id" binding:"required"`
+	Amount     int64   `json:"amount" binding:"gte=0"`     // exact: cents
+	Percentage float64 `json:"percentage" binding:"gte=0"` // percentage: 0-100, up to two decimals
+	Shares     int64   `json:"shares" binding:"gte=0"`     // shares: relative weight
+}
+
+// ItemRequest is one line of an itemized receipt, shared equally by its users.
+type ItemRequest struct {
+	Name       string `json:"name" binding:"required"`
+	UnitAmount int64  `json:"unit_amount" binding:"required,gt=0"` // cents
+	Quantity   int64  `json:"quantity" binding:"omitempty,gt=0"`   // defaults to 1
+	UserIDs    []uint `json:"user_ids" binding:"required,min=1"`
+}
+
+// PayerRequest is one person's contribution towards paying an expense.
+type PayerRequest struct {
+	UserID uint  `json:"user_id" binding:"required"`
+	Amount int64 `json:"amount" binding:"required,gt=0"` // cents
+}
+
+// CreateExpenseRequest describes an expense. Itemized expenses send items, tax and tip
+// instead of splits; the per-user splits are then derived from the items. An expense paid
+// by several people lists them in payers, whose amounts must add up to amount; payer_id
+// alone means that user paid the whole amount.
 type CreateExpenseRequest struct {
-	PayerID     uint   `json:"payer_id" binding:"required"`
-	Amount      int64  `json:"amount" binding:"required,gt=0"`
-	Description string `json:"description" binding:"required"`
-	Splits      []struct {
-		UserID uint  `json:"user_id" binding:"required"`
-		Amount int64 `json:"amount" binding:"required,gt=0"`
-	} `json:"splits" binding:"required,min=1"`
+	PayerID     uint           `json:"payer_id" binding:"required_without=Payers"`
+	Payers      []PayerRequest `json:"payers" binding:"omitempty,dive"`
+	Amount      int64          `json:"amount" binding:"required,gt=0"`
+	Description string         `json:"description" binding:"required"`
+	Currency    string         `json:"currency" binding:"omitempty,iso4217"` // defaults to the group's base currency
+	Category    string         `json:"category" binding:"omitempty,max=64"`  // defaults to "other"
+	SplitType   string         `json:"split_type" binding:"omitempty,oneof=equal percentage shares exact itemized"`
+	Splits      []SplitRequest `json:"splits" binding:"required_unless=SplitType itemized,dive"`
+	Items       []ItemRequest  `json:"items" binding:"required_if=SplitType itemized,dive"`
+	Tax         int64          `json:"tax" binding:"gte=0"` // itemized: cents, spread proportionally
+	Tip         int64          `json:"tip" binding:"gte=0"` // itemized: cents, spread proportionally
+}
+
+func (r CreateExpenseRequest) toExpenseInput() service.ExpenseInput {
+	return service.ExpenseInput{
+		PayerID:     r.PayerID,
+		Payers:      toPayerInputs(r.Payers),
+		Amount:      r.Amount,
+		Description: r.Description,
+		Currency:    r.Currency,
+		Category:    r.Category,
+		SplitType:   model.SplitType(r.SplitType),
+		Splits:      toSplitInputs(r.Splits),
+		Items:       toItemInputs(r.Items),
+		Tax:         r.Tax,
+		Tip:         r.Tip,
+	}
+}
+
+func toPayerInputs(reqs []PayerRequest) []service.PayerInput {
+	if len(reqs) == 0 {
+		return nil
+	}
+	payers := make([]service.PayerInput, len(reqs))
+	for i, p := range reqs {
+		payers[i] = service.PayerInput{UserID: p.UserID, Amount: p.Amount}
+	}
+	return payers
+}
+
+func toItemInputs(reqs []ItemRequest) []service.ItemInput {
+	items := make([]service.ItemInput, len(reqs))
+	for i, item := range reqs {
+		quantity := item.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		items[i] = service.ItemInput{
+			Name:       item.Name,
+			UnitAmount: item.UnitAmount,
+			Quantity:   quantity,
+			UserIDs:    item.UserIDs,
+		}
+	}
+	return items
+}
+
+func toSplitInputs(reqs []SplitRequest) []service.SplitInput {
+	splits := make([]service.SplitInput, len(reqs))
+	for i, s := range reqs {
+		splits[i] = service.SplitInput{
+			UserID:     s.UserID,
+			Amount:     s.Amount,
+			Percentage: int64(math.Round(s.Percentage * 100)), // basis points
+			Shares:     s.Shares,
+		}
+	}
+	return splits
+}
+
+func isSplitValidationError(err error) bool {
+	return err == service.ErrSplitMismatch ||
+		err == service.ErrInvalidSplit ||
+		err == service.ErrInvalidSplitType ||
+		err == service.ErrPercentageMismatch ||
+		err == service.ErrInvalidItem ||
+		err == service.ErrInvalidPayers ||
+		err == service.ErrPayerMismatch
 }
 
 // AddExpense handles POST /groups/{id}/expenses
@@ -43,23 +146,210 @@ func (h *ExpenseHandler) AddExpense(c *gin.Context) {
 		return
 	}
 
-	splits := make([]model.ExpenseSplit, len(req.Splits))
-	for i, s := range req.Splits {
-		splits[i] = model.ExpenseSplit{
-			UserID: s.UserID,
-			Amount: s.Amount,
+	expense, err := h.expenseService.AddExpense(c.Request.Context(), uint(groupID), req.toExpenseInput())
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
 		}
+		if isSplitValidationError(err) || err == currency.ErrUnsupportedCurrency || err == service.ErrUnknownCategory {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add expense"})
+		return
 	}
 
-	expense, err := h.expenseService.AddExpense(c.Request.Context(), uint(groupID), req.PayerID, req.Amount, req.Description, splits)
+	c.JSON(http.StatusCreated, expense)
+}
+
+// UpdateExpense handles PUT /groups/{id}/expenses/{expenseId}
+func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
 	if err != nil {
-		if err == service.ErrSplitMismatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	expenseIDParam := c.Param("expenseId")
+	expenseID, err := strconv.ParseUint(expenseIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	var req CreateExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense, err := h.expenseService.UpdateExpense(c.Request.Context(), uint(groupID), uint(expenseID), req.toExpenseInput())
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrExpenseNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if isSplitValidationError(err) || err == currency.ErrUnsupportedCurrency || err == service.ErrUnknownCategory {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add expense"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update expense"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, expense)
+	c.JSON(http.StatusOK, expense)
+}
+
+// DeleteExpense handles DELETE /groups/{id}/expenses/{expenseId}
+func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	expenseIDParam := c.Param("expenseId")
+	expenseID, err := strconv.ParseUint(expenseIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	if err := h.expenseService.DeleteExpense(c.Request.Context(), uint(groupID), uint(expenseID)); err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrExpenseNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete expense"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetExpenseHistory handles GET /groups/{id}/expenses/{expenseId}/history
+func (h *ExpenseHandler) GetExpenseHistory(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	expenseIDParam := c.Param("expenseId")
+	expenseID, err := strconv.ParseUint(expenseIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	history, err := h.expenseService.GetExpenseHistory(c.Request.Context(), uint(groupID), uint(expenseID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrExpenseNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expense history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// ListExpensesQuery holds the query parameters of GET /groups/{id}/expenses.
+// Dates are RFC 3339; "to" is exclusive. Amounts are in cents of the group's base currency:
+// min_amount, max_amount and the amount sorts compare each expense at its captured rate.
+type ListExpensesQuery struct {
+	PayerID       uint       `form:"payer_id"`
+	ParticipantID uint       `form:"participant_id"`
+	From          *time.Time `form:"from"`
+	To            *time.Time `form:"to"`
+	MinAmount     *int64     `form:"min_amount" binding:"omitempty,gte=0"`
+	MaxAmount     *int64     `form:"max_amount" binding:"omitempty,gte=0"`
+	Search        string     `form:"q"`
+	Sort          string     `form:"sort" binding:"omitempty,oneof=date_desc date_asc amount_desc amount_asc"`
+	Cursor        string     `form:"cursor"`
+	Limit         int        `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+// ListExpenses handles GET /groups/{id}/expenses
+func (h *ExpenseHandler) ListExpenses(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req ListExpensesQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.expenseService.ListExpenses(c.Request.Context(), repository.ExpenseQuery{
+		GroupID:       uint(groupID),
+		PayerID:       req.PayerID,
+		ParticipantID: req.ParticipantID,
+		From:          req.From,
+		To:            req.To,
+		MinAmount:     req.MinAmount,
+		MaxAmount:     req.MaxAmount,
+		Search:        req.Search,
+		Sort:          repository.ExpenseSort(req.Sort),
+		Cursor:        req.Cursor,
+		Limit:         req.Limit,
+	})
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == repository.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list expenses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// ExportExpenses handles GET /groups/{id}/export.csv
+func (h *ExpenseHandler) ExportExpenses(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="group-%d-expenses.csv"`, groupID))
+
+	if err := h.expenseService.ExportExpenses(c.Request.Context(), uint(groupID), c.Writer); err != nil {
+		if c.Writer.Written() {
+			// The status line and part of the file are already out, so an error body would
+			// only corrupt the CSV; the file ends with a row marking it incomplete instead
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export expenses"})
+	}
 }
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -17,8 +19,9 @@ func NewGroupHandler(groupService service.GroupService) *GroupHandler {
 }
 
 type CreateGroupRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description"`
+	Title        string `json:"title" binding:"required"`
+	Description  string `json:"description"`
+	BaseCurrency string `json:"base_currency" binding:"omitempty,iso4217"`
 }
 
 // CreateGroup handles POST /groups
@@ -29,11 +32,163 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 		return
 	}
 
-	group, err := h.groupService.CreateGroup(c.Request.Context(), req.Title, req.Description)
+	group, err := h.groupService.CreateGroup(c.Request.Context(), req.Title, req.Description, req.BaseCurrency)
 	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
 		return
 	}
 
 	c.JSON(http.StatusCreated, group)
 }
+
+type RenameGroupRequest struct {
+	Title string `json:"title" binding:"required"`
+}
+
+type AddMembersRequest struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1"`
+}
+
+// GetGroup handles GET /groups/{id}
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	group, err := h.groupService.GetGroup(c.Request.Context(), uint(groupID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// RenameGroup handles PATCH /groups/{id}
+func (h *GroupHandler) RenameGroup(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req RenameGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.groupService.RenameGroup(c.Request.Context(), uint(groupID), req.Title)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// GetMembers handles GET /groups/{id}/members
+func (h *GroupHandler) GetMembers(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	members, err := h.groupService.GetMembers(c.Request.Context(), uint(groupID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMembers handles POST /groups/{id}/members
+func (h *GroupHandler) AddMembers(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req AddMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	members, err := h.groupService.AddMembers(c.Request.Context(), uint(groupID), req.UserIDs)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrUserNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// RemoveMember handles DELETE /groups/{id}/members/{userId}
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	userIDParam := c.Param("userId")
+	userID, err := strconv.ParseUint(userIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	err = h.groupService.RemoveMember(c.Request.Context(), uint(groupID), uint(userID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrNotMember {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		var outstanding *service.OutstandingBalanceError
+		if errors.As(err, &outstanding) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   err.Error(),
+				"user_id": outstanding.UserID,
+				"balance": outstanding.Balance,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
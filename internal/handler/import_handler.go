@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/importer"
+	"expense-tracker/internal/service"
+)
+
+// maxImportSize caps the size of an uploaded CSV file.
+const maxImportSize = 10 << 20
+
+type ImportHandler struct {
+	importService service.ImportService
+}
+
+func NewImportHandler(importService service.ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// ImportQuery holds the query parameters of POST /groups/{id}/import. The column
+// parameters only apply to the generic format and override importer.DefaultMapping.
+type ImportQuery struct {
+	Format             string `form:"format" binding:"omitempty,oneof=splitwise generic"`
+	DryRun             bool   `form:"dry_run"`
+	Currency           string `form:"currency" binding:"omitempty,iso4217"` // generic rows without a currency
+	DateColumn         string `form:"date_column"`
+	DescriptionColumn  string `form:"description_column"`
+	CategoryColumn     string `form:"category_column"`
+	AmountColumn       string `form:"amount_column"`
+	CurrencyColumn     string `form:"currency_column"`
+	PayerColumn        string `form:"payer_column"`
+	ParticipantsColumn string `form:"participants_column"`
+	DateLayout         string `form:"date_layout"`
+}
+
+// Import handles POST /groups/{id}/import with the CSV file as the request body
+func (h *ImportHandler) Import(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req ImportQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var records []importer.Record
+	if req.Format == "" || req.Format == string(importer.FormatSplitwise) {
+		records, err = importer.ParseSplitwise(body)
+	} else {
+		records, err = importer.ParseGeneric(body, importer.Mapping{
+			Date:         req.DateColumn,
+			Description:  req.DescriptionColumn,
+			Category:     req.CategoryColumn,
+			Amount:       req.AmountColumn,
+			Currency:     req.CurrencyColumn,
+			Payer:        req.PayerColumn,
+			Participants: req.ParticipantsColumn,
+			DateLayout:   req.DateLayout,
+		}, req.Currency)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "CSV file is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// One activity per row is fine for the feed, but not as emails and webhook deliveries
+	ctx := service.WithQuietActivities(c.Request.Context())
+	report, err := h.importService.Import(ctx, uint(groupID), records, req.DryRun)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import expenses"})
+		return
+	}
+
+	status := http.StatusCreated
+	if req.DryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/service"
+)
+
+type NotificationHandler struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// NotificationPreferencesRequest replaces all of a user's email preferences at once.
+type NotificationPreferencesRequest struct {
+	ExpenseAdded    *bool `json:"expense_added" binding:"required"`
+	PaymentReceived *bool `json:"payment_received" binding:"required"`
+	WeeklyDigest    *bool `json:"weekly_digest" binding:"required"`
+}
+
+// GetPreferences handles GET /users/{id}/notifications
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userIDParam := c.Param("id")
+	userID, err := strconv.ParseUint(userIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(c.Request.Context(), uint(userID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles PUT /users/{id}/notifications
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userIDParam := c.Param("id")
+	userID, err := strconv.ParseUint(userIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.notificationService.UpdatePreferences(c.Request.Context(), &model.NotificationPreferences{
+		UserID:          uint(userID),
+		ExpenseAdded:    *req.ExpenseAdded,
+		PaymentReceived: *req.PaymentReceived,
+		WeeklyDigest:    *req.WeeklyDigest,
+	})
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/service"
+)
+
+type PaymentHandler struct {
+	paymentService service.PaymentService
+}
+
+func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService}
+}
+
+type RecordPaymentRequest struct {
+	FromUserID uint   `json:"from_user_id" binding:"required"`
+	ToUserID   uint   `json:"to_user_id" binding:"required"`
+	Amount     int64  `json:"amount" binding:"required,gt=0"`
+	Note       string `json:"note"`
+}
+
+// RecordPayment handles POST /groups/{id}/payments
+func (h *PaymentHandler) RecordPayment(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req RecordPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.paymentService.RecordPayment(c.Request.Context(), uint(groupID), req.FromUserID, req.ToUserID, req.Amount, req.Note)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrSelfPayment {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/currency"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/service"
+)
+
+type RecurringExpenseHandler struct {
+	recurringService service.RecurringExpenseService
+}
+
+func NewRecurringExpenseHandler(recurringService service.RecurringExpenseService) *RecurringExpenseHandler {
+	return &RecurringExpenseHandler{recurringService: recurringService}
+}
+
+type CreateRecurringExpenseRequest struct {
+	CreateExpenseRequest
+	Frequency  string     `json:"frequency" binding:"required,oneof=daily weekly monthly yearly"`
+	Interval   int        `json:"interval" binding:"gte=0"`            // every N periods, defaults to 1
+	DayOfMonth int        `json:"day_of_month" binding:"gte=0,lte=31"` // monthly/yearly, defaults to the start date's day
+	StartDate  time.Time  `json:"start_date" binding:"required"`       // RFC 3339
+	EndDate    *time.Time `json:"end_date"`                            // RFC 3339, inclusive
+}
+
+// CreateRecurringExpense handles POST /groups/{id}/recurring-expenses
+func (h *RecurringExpenseHandler) CreateRecurringExpense(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req CreateRecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Each occurrence is posted with a single payer
+	if len(req.Payers) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Recurring expenses support a single payer_id, not payers"})
+		return
+	}
+
+	splits := make([]model.RecurringExpenseSplit, len(req.Splits))
+	for i, in := range toSplitInputs(req.Splits) {
+		splits[i] = model.RecurringExpenseSplit{
+			UserID:     in.UserID,
+			Amount:     in.Amount,
+			Percentage: in.Percentage,
+			Shares:     in.Shares,
+		}
+	}
+
+	recurring, err := h.recurringService.CreateRecurringExpense(c.Request.Context(), &model.RecurringExpense{
+		GroupID:     uint(groupID),
+		PayerID:     req.PayerID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Category:    req.Category,
+		Currency:    req.Currency,
+		SplitType:   model.SplitType(req.SplitType),
+		Frequency:   model.RecurrenceFrequency(req.Frequency),
+		Interval:    req.Interval,
+		DayOfMonth:  req.DayOfMonth,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Splits:      splits,
+	})
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if isSplitValidationError(err) || err == service.ErrInvalidRecurrence || err == service.ErrUnknownCategory || err == currency.ErrUnsupportedCurrency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring expense"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, recurring)
+}
+
+// GetRecurringExpenses handles GET /groups/{id}/recurring-expenses
+func (h *RecurringExpenseHandler) GetRecurringExpenses(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	recurring, err := h.recurringService.GetRecurringExpenses(c.Request.Context(), uint(groupID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recurring expenses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// DeleteRecurringExpense handles DELETE /groups/{id}/recurring-expenses/{recurringId}
+func (h *RecurringExpenseHandler) DeleteRecurringExpense(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	recurringIDParam := c.Param("recurringId")
+	recurringID, err := strconv.ParseUint(recurringIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring expense ID"})
+		return
+	}
+
+	if err := h.recurringService.DeleteRecurringExpense(c.Request.Context(), uint(groupID), uint(recurringID)); err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrRecurringExpenseNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recurring expense"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
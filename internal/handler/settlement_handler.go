@@ -6,6 +6,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"expense-tracker/internal/algorithm"
+	"expense-tracker/internal/model"
 	"expense-tracker/internal/service"
 )
 
@@ -28,6 +30,9 @@ func (h *SettlementHandler) GetBalances(c *gin.Context) {
 
 	balances, err := h.settlementService.CalculateBalances(c.Request.Context(), uint(groupID))
 	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate balances"})
 		return
 	}
@@ -35,7 +40,7 @@ func (h *SettlementHandler) GetBalances(c *gin.Context) {
 	c.JSON(http.StatusOK, balances)
 }
 
-// GetSettlements handles GET /groups/{id}/settlements
+// GetSettlements handles GET /groups/{id}/settlements?strategy=greedy|optimal&explain=true
 func (h *SettlementHandler) GetSettlements(c *gin.Context) {
 	groupIDParam := c.Param("id")
 	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
@@ -44,11 +49,101 @@ func (h *SettlementHandler) GetSettlements(c *gin.Context) {
 		return
 	}
 
-	settlements, err := h.settlementService.GetSettlements(c.Request.Context(), uint(groupID))
+	strategy, ok := parseStrategy(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid strategy, expected greedy or optimal"})
+		return
+	}
+
+	if c.Query("explain") == "true" {
+		explanation, err := h.settlementService.ExplainSettlements(c.Request.Context(), uint(groupID), strategy)
+		if err != nil {
+			if respondAccessError(c, err) {
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate settlements"})
+			return
+		}
+		c.JSON(http.StatusOK, explanation)
+		return
+	}
+
+	settlements, err := h.settlementService.GetSettlements(c.Request.Context(), uint(groupID), strategy)
 	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate settlements"})
 		return
 	}
 
 	c.JSON(http.StatusOK, settlements)
 }
+
+// GetDebts handles GET /groups/{id}/debts?view=raw|simplified&strategy=greedy|optimal
+func (h *SettlementHandler) GetDebts(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	view := c.DefaultQuery("view", "raw")
+	if view != "raw" && view != "simplified" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view, expected raw or simplified"})
+		return
+	}
+
+	strategy, ok := parseStrategy(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid strategy, expected greedy or optimal"})
+		return
+	}
+
+	debts, err := h.settlementService.GetDebts(c.Request.Context(), uint(groupID), view == "simplified", strategy)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate debts"})
+		return
+	}
+	if debts == nil {
+		debts = []model.Debt{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"view": view, "debts": debts})
+}
+
+// GetUserSettlements handles GET /users/{id}/settlements?strategy=greedy|optimal
+func (h *SettlementHandler) GetUserSettlements(c *gin.Context) {
+	userIDParam := c.Param("id")
+	userID, err := strconv.ParseUint(userIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	strategy, ok := parseStrategy(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid strategy, expected greedy or optimal"})
+		return
+	}
+
+	summary, err := h.settlementService.GetUserSettlements(c.Request.Context(), uint(userID), strategy)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate settlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func parseStrategy(c *gin.Context) (algorithm.Strategy, bool) {
+	strategy := algorithm.Strategy(c.DefaultQuery("strategy", string(algorithm.StrategyGreedy)))
+	return strategy, strategy == algorithm.StrategyGreedy || strategy == algorithm.StrategyOptimal
+}
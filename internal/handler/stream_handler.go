@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/service"
+	"expense-tracker/internal/stream"
+)
+
+const (
+	// streamHeartbeat keeps idle streams from being cut by proxies and lets the server notice
+	// clients that have gone away.
+	streamHeartbeat = 15 * time.Second
+	// streamRetry tells clients how long to wait before reconnecting.
+	streamRetry = 3 * time.Second
+)
+
+type StreamHandler struct {
+	streamService service.StreamService
+}
+
+func NewStreamHandler(streamService service.StreamService) *StreamHandler {
+	return &StreamHandler{streamService: streamService}
+}
+
+// IssueStreamToken handles POST /groups/{id}/stream/token
+//
+// Browsers' EventSource can't send an Authorization header, so they fetch a short-lived
+// token here and open the stream with it in the token query parameter.
+func (h *StreamHandler) IssueStreamToken(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	token, err := h.streamService.IssueToken(c.Request.Context(), uint(groupID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue stream token"})
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// StreamGroup handles GET /groups/{id}/stream
+//
+// The response is a Server-Sent Events stream of the group's expense, payment and balance
+// changes. A client that reconnects with Last-Event-ID receives the events it missed. It
+// takes a bearer token, or a stream token in the token query parameter.
+func (h *StreamHandler) StreamGroup(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	sub, initial, err := h.streamService.Subscribe(c.Request.Context(), uint(groupID), c.GetHeader("Last-Event-ID"))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == stream.ErrClosed {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open stream"})
+		return
+	}
+	defer h.streamService.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+	for _, e := range initial {
+		writeStreamEvent(c.Writer, e)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.Events():
+			// Closed on shutdown, when the caller leaves the group or when they fell too far
+			// behind; a client that is still allowed in reconnects and catches up
+			if !ok {
+				return
+			}
+			writeStreamEvent(c.Writer, e)
+		case <-heartbeat.C:
+			io.WriteString(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeStreamEvent(w io.Writer, e stream.Event) {
+	if e.ID != 0 {
+		fmt.Fprintf(w, "id: %d\n", e.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, e.Data)
+}
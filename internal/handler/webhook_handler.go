@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/service"
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret"` // generated when empty
+	Events []string `json:"events"` // defaults to every event
+}
+
+// CreateWebhookResponse is the only response that includes the signing secret.
+type CreateWebhookResponse struct {
+	*model.Webhook
+	Secret string `json:"secret"`
+}
+
+// DeliveryLogQuery holds the query parameters of the delivery log.
+type DeliveryLogQuery struct {
+	Limit int `form:"limit" binding:"omitempty,gte=1,lte=200"`
+}
+
+// CreateWebhook handles POST /groups/{id}/webhooks
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events := make([]model.ActivityType, len(req.Events))
+	for i, e := range req.Events {
+		events[i] = model.ActivityType(e)
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), &model.Webhook{
+		GroupID: uint(groupID),
+		URL:     req.URL,
+		Secret:  req.Secret,
+		Events:  events,
+	})
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrInvalidWebhook {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateWebhookResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// GetWebhooks handles GET /groups/{id}/webhooks
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	groupIDParam := c.Param("id")
+	groupID, err := strconv.ParseUint(groupIDParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	webhooks, err := h.webhookService.GetWebhooks(c.Request.Context(), uint(groupID))
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// DeleteWebhook handles DELETE /groups/{id}/webhooks/{webhookId}
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	groupID, webhookID, ok := parseWebhookPath(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), groupID, webhookID); err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrWebhookNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDeliveries handles GET /groups/{id}/webhooks/{webhookId}/deliveries
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	groupID, webhookID, ok := parseWebhookPath(c)
+	if !ok {
+		return
+	}
+
+	var req DeliveryLogQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request.Context(), groupID, webhookID, req.Limit)
+	if err != nil {
+		if respondAccessError(c, err) {
+			return
+		}
+		if err == service.ErrWebhookNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// parseWebhookPath reads the group and webhook IDs of a webhook-scoped route,
+// responding with 400 when either is malformed.
+func parseWebhookPath(c *gin.Context) (uint, uint, bool) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return 0, 0, false
+	}
+	webhookID, err := strconv.ParseUint(c.Param("webhookId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return 0, 0, false
+	}
+	return uint(groupID), uint(webhookID), true
+}
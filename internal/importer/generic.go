@@ -0,0 +1,182 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/currency"
+	"expense-tracker/internal/model"
+)
+
+// Mapping tells ParseGeneric which header names hold which fields. Header names are matched
+// case-insensitively; Category and Currency are optional columns.
+//
+// The participants column lists names separated by semicolons. Plain names ("Ana;Bo") split
+// the amount equally; "name=amount" pairs ("Ana=12.50;Bo=7.50") give exact shares.
+type Mapping struct {
+	Date         string
+	Description  string
+	Category     string
+	Amount       string
+	Currency     string
+	Payer        string
+	Participants string
+	DateLayout   string // A time.Parse layout
+}
+
+// DefaultMapping is used for fields left empty in a Mapping.
+var DefaultMapping = Mapping{
+	Date:         "date",
+	Description:  "description",
+	Category:     "category",
+	Amount:       "amount",
+	Currency:     "currency",
+	Payer:        "payer",
+	Participants: "participants",
+	DateLayout:   "2006-01-02",
+}
+
+func (m Mapping) withDefaults() Mapping {
+	pick := func(value, fallback string) string {
+		if strings.TrimSpace(value) == "" {
+			return fallback
+		}
+		return strings.TrimSpace(value)
+	}
+	return Mapping{
+		Date:         pick(m.Date, DefaultMapping.Date),
+		Description:  pick(m.Description, DefaultMapping.Description),
+		Category:     pick(m.Category, DefaultMapping.Category),
+		Amount:       pick(m.Amount, DefaultMapping.Amount),
+		Currency:     pick(m.Currency, DefaultMapping.Currency),
+		Payer:        pick(m.Payer, DefaultMapping.Payer),
+		Participants: pick(m.Participants, DefaultMapping.Participants),
+		DateLayout:   pick(m.DateLayout, DefaultMapping.DateLayout),
+	}
+}
+
+// ParseGeneric reads a CSV with one expense per row, using mapping to find the columns.
+// Rows without a currency use defaultCurrency. When that is empty too, the currency is left
+// empty and the amounts unparsed until Record.ResolveCurrency is called with the group's.
+func ParseGeneric(r io.Reader, mapping Mapping, defaultCurrency string) ([]Record, error) {
+	mapping = mapping.withDefaults()
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	column := func(name string, required bool) (int, error) {
+		i, ok := columns[strings.ToLower(name)]
+		if !ok {
+			if required {
+				return 0, fmt.Errorf("%w: %q", ErrMissingColumn, name)
+			}
+			return -1, nil
+		}
+		return i, nil
+	}
+
+	var idx struct{ date, description, category, amount, currency, payer, participants int }
+	for _, c := range []struct {
+		target   *int
+		name     string
+		required bool
+	}{
+		{&idx.date, mapping.Date, true},
+		{&idx.description, mapping.Description, true},
+		{&idx.category, mapping.Category, false},
+		{&idx.amount, mapping.Amount, true},
+		{&idx.currency, mapping.Currency, false},
+		{&idx.payer, mapping.Payer, true},
+		{&idx.participants, mapping.Participants, true},
+	} {
+		if *c.target, err = column(c.name, c.required); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []Record
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if isBlank(fields) {
+			continue
+		}
+
+		field := func(i int) string {
+			if i < 0 || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+
+		rec := Record{
+			Line:        line,
+			Description: field(idx.description),
+			Category:    field(idx.category),
+			Payer:       field(idx.payer),
+			Currency:    field(idx.currency),
+		}
+		if rec.Currency == "" {
+			rec.Currency = defaultCurrency
+		}
+		if rec.Currency != "" {
+			rec.Currency = currency.Normalize(rec.Currency)
+		}
+		rec.Err = parseGenericRow(&rec, field(idx.date), field(idx.amount), field(idx.participants), mapping.DateLayout)
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func parseGenericRow(rec *Record, date, amount, participants, layout string) error {
+	var err error
+	if rec.Date, err = time.Parse(layout, date); err != nil {
+		return fmt.Errorf("%w: invalid date %q", ErrMalformedRecord, date)
+	}
+	if rec.Payer == "" {
+		return ErrNoPayer
+	}
+	rec.amount = amount
+
+	rec.SplitType = model.SplitTypeEqual
+	for _, part := range strings.Split(participants, ";") {
+		name, value, exact := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		share := Share{Name: name}
+		if exact {
+			share.amount = strings.TrimSpace(value)
+			rec.SplitType = model.SplitTypeExact
+		}
+		rec.Shares = append(rec.Shares, share)
+	}
+	if len(rec.Shares) == 0 {
+		return fmt.Errorf("%w: no participants", ErrMalformedRecord)
+	}
+
+	if rec.Currency == "" {
+		return nil
+	}
+	return rec.parseAmounts()
+}
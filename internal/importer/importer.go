@@ -0,0 +1,92 @@
+// Package importer reads expense history exported from other tools into records that the
+// import service can validate and insert. It only parses; it never touches the database.
+package importer
+
+import (
+	"errors"
+	"time"
+
+	"expense-tracker/internal/currency"
+	"expense-tracker/internal/model"
+)
+
+// Format names an input format.
+type Format string
+
+const (
+	FormatSplitwise Format = "splitwise"
+	FormatGeneric   Format = "generic"
+)
+
+var (
+	ErrUnknownFormat   = errors.New("unknown import format, expected splitwise or generic")
+	ErrMissingColumn   = errors.New("the CSV header is missing a required column")
+	ErrMultiplePayers  = errors.New("expenses paid by more than one person are not supported")
+	ErrNoPayer         = errors.New("could not tell who paid for this expense")
+	ErrInvalidPayment  = errors.New("a payment must be from exactly one person to exactly one other")
+	ErrMalformedRecord = errors.New("malformed row")
+)
+
+// Share is one participant's part of an imported expense. Amount is only set for exact splits.
+type Share struct {
+	Name   string
+	Amount int64
+	amount string // Amount as written, while the currency is still unknown
+}
+
+// Record is one parsed row. People are identified by name only; the import service maps
+// them onto users. When Payment is set, Payer paid Shares[0] back instead of buying something.
+// Rows that could not be parsed carry Err and should be reported rather than imported.
+type Record struct {
+	Line        int
+	Date        time.Time
+	Description string
+	Category    string
+	Currency    string
+	Amount      int64
+	Payer       string
+	SplitType   model.SplitType
+	Shares      []Share
+	Payment     bool
+	Err         error
+
+	amount string // Amount as written, while the currency is still unknown
+}
+
+// ResolveCurrency gives a record that names no currency the fallback one, and parses the
+// amounts that were waiting on it: how many decimals an amount has depends on its currency.
+func (r *Record) ResolveCurrency(fallback string) {
+	if r.Currency != "" {
+		return
+	}
+	r.Currency = currency.Normalize(fallback)
+	if r.Err == nil {
+		r.Err = r.parseAmounts()
+	}
+}
+
+// parseAmounts parses the amounts kept as written, now that the currency is known.
+func (r *Record) parseAmounts() error {
+	var err error
+	if r.Amount, err = currency.ParseAmount(r.amount, r.Currency); err != nil {
+		return err
+	}
+	for i := range r.Shares {
+		if r.Shares[i].amount == "" {
+			continue
+		}
+		if r.Shares[i].Amount, err = currency.ParseAmount(r.Shares[i].amount, r.Currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names returns everyone mentioned in the record, payer first.
+func (r Record) Names() []string {
+	names := []string{r.Payer}
+	for _, s := range r.Shares {
+		names = append(names, s.Name)
+	}
+	return names
+}
@@ -0,0 +1,152 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/currency"
+	"expense-tracker/internal/model"
+)
+
+// splitwiseColumns are the fixed leading columns of a Splitwise export. Every column after them
+// holds one person's net effect of the row: what they paid minus what they owe.
+var splitwiseColumns = []string{"date", "description", "category", "cost", "currency"}
+
+// splitwisePaymentCategory marks settle-up rows in a Splitwise export.
+const splitwisePaymentCategory = "payment"
+
+// ParseSplitwise reads a Splitwise group export. Splitwise only records each person's net
+// effect, so the payer is the single person with a positive net: they paid the full cost and
+// owe cost minus their net; everyone with a negative net owes its absolute value.
+func ParseSplitwise(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) <= len(splitwiseColumns) {
+		return nil, ErrMissingColumn
+	}
+	for i, name := range splitwiseColumns {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), name) {
+			return nil, fmt.Errorf("%w: expected %q in column %d", ErrMissingColumn, name, i+1)
+		}
+	}
+	people := header[len(splitwiseColumns):]
+
+	var records []Record
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		// Splitwise separates the rows from a trailing "Total balance" summary with a blank line
+		if isBlank(fields) {
+			continue
+		}
+		if len(fields) > 1 && strings.EqualFold(strings.TrimSpace(fields[1]), "total balance") {
+			continue
+		}
+
+		records = append(records, parseSplitwiseRow(line, fields, people))
+	}
+	return records, nil
+}
+
+func parseSplitwiseRow(line int, fields []string, people []string) Record {
+	rec := Record{Line: line, SplitType: model.SplitTypeExact}
+	if len(fields) != len(splitwiseColumns)+len(people) {
+		rec.Err = fmt.Errorf("%w: expected %d columns, got %d", ErrMalformedRecord, len(splitwiseColumns)+len(people), len(fields))
+		return rec
+	}
+
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(fields[0]))
+	if err != nil {
+		rec.Err = fmt.Errorf("%w: invalid date %q", ErrMalformedRecord, fields[0])
+		return rec
+	}
+	rec.Date = date
+	rec.Description = strings.TrimSpace(fields[1])
+	rec.Category = strings.TrimSpace(fields[2])
+	rec.Currency = currency.Normalize(fields[4])
+
+	if rec.Amount, err = currency.ParseAmount(fields[3], rec.Currency); err != nil {
+		rec.Err = err
+		return rec
+	}
+
+	type net struct {
+		name   string
+		amount int64
+	}
+	var creditors, debtors []net
+	for i, name := range people {
+		value := strings.TrimSpace(fields[len(splitwiseColumns)+i])
+		if value == "" {
+			continue
+		}
+		amount, err := currency.ParseAmount(value, rec.Currency)
+		if err != nil {
+			rec.Err = err
+			return rec
+		}
+		switch {
+		case amount > 0:
+			creditors = append(creditors, net{name: strings.TrimSpace(name), amount: amount})
+		case amount < 0:
+			debtors = append(debtors, net{name: strings.TrimSpace(name), amount: -amount})
+		}
+	}
+
+	if strings.EqualFold(rec.Category, splitwisePaymentCategory) {
+		if len(creditors) != 1 || len(debtors) != 1 {
+			rec.Err = ErrInvalidPayment
+			return rec
+		}
+		rec.Payment = true
+		rec.Category = ""
+		rec.Payer = creditors[0].name
+		rec.Shares = []Share{{Name: debtors[0].name, Amount: rec.Amount}}
+		return rec
+	}
+
+	switch len(creditors) {
+	case 0:
+		rec.Err = ErrNoPayer
+		return rec
+	case 1:
+	default:
+		rec.Err = ErrMultiplePayers
+		return rec
+	}
+
+	payer := creditors[0]
+	rec.Payer = payer.name
+	if own := rec.Amount - payer.amount; own != 0 {
+		rec.Shares = append(rec.Shares, Share{Name: payer.name, Amount: own})
+	}
+	for _, d := range debtors {
+		rec.Shares = append(rec.Shares, Share{Name: d.name, Amount: d.amount})
+	}
+	return rec
+}
+
+func isBlank(fields []string) bool {
+	for _, f := range fields {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"expense-tracker/internal/auth"
+)
+
+// ContextUserIDKey is the gin context key holding the authenticated user's ID.
+const ContextUserIDKey = "userID"
+
+// RequireAuth is a Gin middleware that rejects requests without a valid bearer token
+// and injects the caller's user ID into both the gin context and the request context.
+func RequireAuth(tokens *auth.TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		userID, err := tokens.Parse(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		setUserID(c, userID)
+		c.Next()
+	}
+}
+
+// RequireStreamAuth authenticates like RequireAuth, but also accepts a stream token for the
+// group in the route's :id in the token query parameter, since a browser's EventSource can't
+// send an Authorization header.
+func RequireStreamAuth(tokens *auth.TokenManager) gin.HandlerFunc {
+	requireAuth := RequireAuth(tokens)
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			requireAuth(c)
+			return
+		}
+
+		groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+			return
+		}
+		userID, err := tokens.ParseStreamToken(token, uint(groupID))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		setUserID(c, userID)
+		c.Next()
+	}
+}
+
+// setUserID injects the caller's user ID into both the gin context and the request context.
+func setUserID(c *gin.Context, userID uint) {
+	c.Set(ContextUserIDKey, userID)
+	c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), userID))
+}
@@ -22,6 +22,11 @@ func RequestLogger() gin.HandlerFunc {
 
 		path := c.Request.URL.Path
 		query := c.Request.URL.RawQuery
+		// Stream tokens are credentials, however short-lived
+		if values := c.Request.URL.Query(); values.Has("token") {
+			values.Set("token", "REDACTED")
+			query = values.Encode()
+		}
 
 		c.Next()
 
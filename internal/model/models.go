@@ -5,18 +5,26 @@ import (
 )
 
 // User represents a user in the system.
+// Email is unique among users that have one; PasswordHash is a bcrypt hash and never serialized.
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"not null"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name" gorm:"not null"`
+	Email        string    `json:"email,omitempty" gorm:"uniqueIndex:idx_users_email,where:email <> ''"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
 // Group represents a collection of users who share expenses, like a trip or roommates.
+// Balances and settlements for the group are expressed in its BaseCurrency.
 type Group struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Title       string    `json:"title" gorm:"not null"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Title        string    `json:"title" gorm:"not null"`
+	Description  string    `json:"description"`
+	BaseCurrency string    `json:"base_currency" gorm:"size:3;not null;default:USD"` // ISO-4217 code
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Members is only populated when a single group is fetched with its members
+	Members []User `json:"members,omitempty" gorm:"-"`
 }
 
 // GroupMember represents the many-to-many relationship between Users and Groups.
@@ -25,18 +33,80 @@ type GroupMember struct {
 	UserID  uint `json:"user_id" gorm:"primaryKey"`
 }
 
+// DefaultCategories are the expense categories available in every group. Groups can add their own.
+var DefaultCategories = []string{"food", "transport", "lodging", "utilities", "other"}
+
+// DefaultCategory is used for expenses that are created without a category.
+const DefaultCategory = "other"
+
+// Category is a custom expense category defined by a group, in addition to DefaultCategories.
+type Category struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	GroupID   uint      `json:"group_id" gorm:"not null;uniqueIndex:idx_categories_group_name"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_categories_group_name"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// SplitType describes how an expense's amount was divided between its participants.
+type SplitType string
+
+const (
+	SplitTypeExact      SplitType = "exact"      // Explicit per-user cent amounts
+	SplitTypeEqual      SplitType = "equal"      // Divided evenly between all participants
+	SplitTypePercentage SplitType = "percentage" // Divided by per-user percentages adding up to 100
+	SplitTypeShares     SplitType = "shares"     // Divided proportionally to per-user weights
+	SplitTypeItemized   SplitType = "itemized"   // Derived from line items, with tax and tip spread proportionally
+)
+
 // Expense represents a single expense paid by someone in a group.
 // The Amount is stored in integer cents to completely avoid floating-point math issues.
+// Amount and the split amounts are in the expense's own Currency; ExchangeRate is the value
+// of one unit of that currency in the group's base currency, captured when the expense was
+// recorded so that its converted value never shifts afterwards.
 type Expense struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	GroupID     uint      `json:"group_id" gorm:"not null;index"`
-	PayerID     uint      `json:"payer_id" gorm:"not null;index"`
-	Amount      int64     `json:"amount" gorm:"not null"` // Amount in cents
-	Description string    `json:"description" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	GroupID      uint      `json:"group_id" gorm:"not null;index"`
+	PayerID      uint      `json:"payer_id" gorm:"not null;index"` // The payer who paid the most; see Payers
+	Amount       int64     `json:"amount" gorm:"not null"`         // Amount in cents
+	Description  string    `json:"description" gorm:"not null"`
+	Category     string    `json:"category" gorm:"not null;default:other"`
+	SplitType    SplitType `json:"split_type" gorm:"not null;default:exact"`
+	Currency     string    `json:"currency" gorm:"size:3;not null;default:USD"` // ISO-4217 code
+	ExchangeRate float64   `json:"exchange_rate" gorm:"not null;default:1"`
+	Tax          int64     `json:"tax,omitempty" gorm:"not null;default:0"` // Itemized expenses only
+	Tip          int64     `json:"tip,omitempty" gorm:"not null;default:0"` // Itemized expenses only
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
 
 	// Relationships
+	Payers []ExpensePayer `json:"payers,omitempty" gorm:"foreignKey:ExpenseID"`
 	Splits []ExpenseSplit `json:"splits,omitempty" gorm:"foreignKey:ExpenseID"`
+	Items  []ExpenseItem  `json:"items,omitempty" gorm:"foreignKey:ExpenseID"`
+}
+
+// ExpensePayer is how much one person paid towards an expense. The payers of an expense add
+// up to its Amount; a single payer pays all of it.
+type ExpensePayer struct {
+	ID        uint  `json:"id" gorm:"primaryKey"`
+	ExpenseID uint  `json:"expense_id" gorm:"not null;index"`
+	UserID    uint  `json:"user_id" gorm:"not null;index"`
+	Amount    int64 `json:"amount" gorm:"not null"` // Amount in cents
+}
+
+// ExpenseItem is one line of an itemized receipt. Its total (UnitAmount * Quantity) is shared
+// equally by the assigned users.
+type ExpenseItem struct {
+	ID         uint                  `json:"id" gorm:"primaryKey"`
+	ExpenseID  uint                  `json:"expense_id" gorm:"not null;index"`
+	Name       string                `json:"name" gorm:"not null"`
+	UnitAmount int64                 `json:"unit_amount" gorm:"not null"` // Amount in cents
+	Quantity   int64                 `json:"quantity" gorm:"not null;default:1"`
+	Assignees  []ExpenseItemAssignee `json:"assignees" gorm:"foreignKey:ExpenseItemID"`
+}
+
+// ExpenseItemAssignee links a line item to one of the users who shared it.
+type ExpenseItemAssignee struct {
+	ExpenseItemID uint `json:"-" gorm:"primaryKey"`
+	UserID        uint `json:"user_id" gorm:"primaryKey"`
 }
 
 // ExpenseSplit represents how much a specific user owes for a particular expense.
@@ -47,16 +117,430 @@ type ExpenseSplit struct {
 	Amount    int64 `json:"amount" gorm:"not null"` // Amount in cents
 }
 
+// ExpenseAction is the kind of write recorded in an expense's history.
+type ExpenseAction string
+
+const (
+	ExpenseCreated ExpenseAction = "created"
+	ExpenseUpdated ExpenseAction = "updated"
+	ExpenseDeleted ExpenseAction = "deleted"
+)
+
+// ExpenseVersion is an immutable snapshot of an expense, written in the same transaction as
+// every change to it. Versions are numbered from 1 per expense and outlive the expense itself.
+// ActorID is nil for versions backfilled from before history was recorded.
+type ExpenseVersion struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	ExpenseID uint            `json:"expense_id" gorm:"not null;uniqueIndex:idx_expense_versions_expense_version"`
+	GroupID   uint            `json:"group_id" gorm:"not null;index"`
+	Version   int             `json:"version" gorm:"not null;uniqueIndex:idx_expense_versions_expense_version"`
+	Action    ExpenseAction   `json:"action" gorm:"not null"`
+	ActorID   *uint           `json:"actor_id"`
+	Snapshot  ExpenseSnapshot `json:"snapshot" gorm:"type:jsonb;serializer:json;not null"`
+	CreatedAt time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ExpenseSnapshot is the state of an expense and its splits as of one version. For a deleted
+// expense it is the state the expense was in when it was deleted.
+type ExpenseSnapshot struct {
+	PayerID      uint            `json:"payer_id"`
+	Payers       []SnapshotShare `json:"payers"`
+	Amount       int64           `json:"amount"`
+	Description  string          `json:"description"`
+	Category     string          `json:"category"`
+	SplitType    SplitType       `json:"split_type"`
+	Currency     string          `json:"currency"`
+	ExchangeRate float64         `json:"exchange_rate"`
+	Tax          int64           `json:"tax"`
+	Tip          int64           `json:"tip"`
+	Splits       []SnapshotShare `json:"splits"`
+	Items        []SnapshotItem  `json:"items,omitempty"`
+}
+
+// SnapshotShare is one user's cent amount within a snapshot, either paid or owed.
+type SnapshotShare struct {
+	UserID uint  `json:"user_id"`
+	Amount int64 `json:"amount"`
+}
+
+// SnapshotItem is one line item within a snapshot.
+type SnapshotItem struct {
+	Name       string `json:"name"`
+	UnitAmount int64  `json:"unit_amount"`
+	Quantity   int64  `json:"quantity"`
+	UserIDs    []uint `json:"user_ids"`
+}
+
+// ExpenseHistoryEntry is one version of an expense together with what changed since the
+// version before it. The first version lists every field as changed from nothing.
+type ExpenseHistoryEntry struct {
+	Version   int             `json:"version"`
+	Action    ExpenseAction   `json:"action"`
+	ActorID   *uint           `json:"actor_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Changes   []FieldChange   `json:"changes"`
+	Snapshot  ExpenseSnapshot `json:"snapshot"`
+}
+
+// FieldChange is a single field that differs between two versions of an expense. Per-user
+// amounts are reported as "splits.<user_id>" and "payers.<user_id>", with a nil From or To when
+// the user was added or removed.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Attachment is a file, such as a receipt photo, attached to an expense. The file itself is
+// kept in blob storage under StorageKey.
+type Attachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ExpenseID    uint      `json:"expense_id" gorm:"not null;index"`
+	FileName     string    `json:"file_name" gorm:"not null"`
+	ContentType  string    `json:"content_type" gorm:"not null"`
+	Size         int64     `json:"size"` // In bytes
+	StorageKey   string    `json:"-" gorm:"not null"`
+	UploadedByID uint      `json:"uploaded_by_id" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
 // Settlement represents a calculated payment that needs to be made from one user to another.
 // It is unpersisted, and only used for returning the results of the settlement algorithm.
 type Settlement struct {
+	FromUserID uint   `json:"from_user_id"`
+	ToUserID   uint   `json:"to_user_id"`
+	Amount     int64  `json:"amount"`             // Amount in cents
+	Currency   string `json:"currency,omitempty"` // Only set when settlements span several groups
+}
+
+// SettlementStep explains one transfer suggested by the settlement algorithm: the outstanding
+// balances right before and after it was matched, and the expenses and payments that put the
+// two users in that position. It is unpersisted, and only used for the explain mode.
+type SettlementStep struct {
+	Step           int                   `json:"step"`
+	Settlement     Settlement            `json:"settlement"`
+	BalancesBefore []UserBalance         `json:"balances_before"`
+	BalancesAfter  []UserBalance         `json:"balances_after"`
+	Contributions  []BalanceContribution `json:"contributions"`
+}
+
+// BalanceContribution is the effect a single expense or payment had on the two users of a transfer.
+type BalanceContribution struct {
+	Kind           string `json:"kind"` // "expense" or "payment"
+	ID             uint   `json:"id"`
+	Description    string `json:"description"`
+	FromUserEffect int64  `json:"from_user_effect"` // Change to the paying user's balance, in cents
+	ToUserEffect   int64  `json:"to_user_effect"`   // Change to the receiving user's balance, in cents
+}
+
+// SettlementExplanation is the response of the settlements endpoint in explain mode.
+type SettlementExplanation struct {
+	Strategy    string           `json:"strategy"`
+	Settlements []Settlement     `json:"settlements"`
+	Steps       []SettlementStep `json:"steps"`
+}
+
+// CategoryReport is the spending in one category: the total consumed and each member's share of it.
+type CategoryReport struct {
+	Category string        `json:"category"`
+	Total    int64         `json:"total"` // Amount in cents
+	Members  []MemberShare `json:"members"`
+}
+
+// MemberShare is how much a member consumed, based on their expense splits.
+type MemberShare struct {
+	UserID uint  `json:"user_id"`
+	Amount int64 `json:"amount"` // Amount in cents
+}
+
+// CategorySpendingReport is the per-category spending of a group over an optional date range.
+// It is unpersisted; amounts are in the group's base currency.
+type CategorySpendingReport struct {
+	GroupID    uint             `json:"group_id"`
+	Currency   string           `json:"currency"`
+	From       *time.Time       `json:"from,omitempty"`
+	To         *time.Time       `json:"to,omitempty"`
+	Total      int64            `json:"total"`
+	Categories []CategoryReport `json:"categories"`
+}
+
+// AnalyticsInterval is the size of the time buckets in a spending time series.
+type AnalyticsInterval string
+
+const (
+	IntervalDay   AnalyticsInterval = "day"
+	IntervalWeek  AnalyticsInterval = "week" // Weeks start on Monday
+	IntervalMonth AnalyticsInterval = "month"
+)
+
+// SeriesPoint is one time bucket of a spending series. Amounts are in cents.
+type SeriesPoint struct {
+	Period   time.Time `json:"period"`   // Start of the bucket, in UTC
+	Paid     int64     `json:"paid"`     // What the user paid for expenses
+	Consumed int64     `json:"consumed"` // The user's share of expenses
+	Balance  int64     `json:"balance"`  // Running net balance at the end of the bucket, including payments
+}
+
+// SpendingSeries is one user's spending over time in a single currency.
+type SpendingSeries struct {
+	UserID   uint          `json:"user_id"`
+	Currency string        `json:"currency"`
+	Points   []SeriesPoint `json:"points"`
+}
+
+// SpendingAnalytics holds the spending series of a group's members, or of a single user across
+// all of their groups. It is unpersisted; groups with different base currencies are never mixed.
+type SpendingAnalytics struct {
+	GroupID  uint              `json:"group_id,omitempty"`
+	UserID   uint              `json:"user_id,omitempty"`
+	Interval AnalyticsInterval `json:"interval"`
+	From     *time.Time        `json:"from,omitempty"`
+	To       *time.Time        `json:"to,omitempty"`
+	Series   []SpendingSeries  `json:"series"`
+}
+
+// ImportRowResult reports what happened to one row of an import.
+type ImportRowResult struct {
+	Line        int    `json:"line"`
+	Description string `json:"description"`
+	Status      string `json:"status"` // "ok" or "failed"; in a dry run "ok" means the row would import
+	Error       string `json:"error,omitempty"`
+	ExpenseID   uint   `json:"expense_id,omitempty"`
+	PaymentID   uint   `json:"payment_id,omitempty"`
+}
+
+// ImportReport summarises an import. In a dry run nothing is written and UsersCreated lists
+// the users that would be created.
+type ImportReport struct {
+	DryRun       bool              `json:"dry_run"`
+	Imported     int               `json:"imported"`
+	Failed       int               `json:"failed"`
+	UsersCreated []string          `json:"users_created"`
+	Rows         []ImportRowResult `json:"rows"`
+}
+
+// Debt represents how much one user owes another within a group.
+// It is unpersisted; raw debts are derived from who paid for whose splits.
+type Debt struct {
 	FromUserID uint  `json:"from_user_id"`
 	ToUserID   uint  `json:"to_user_id"`
 	Amount     int64 `json:"amount"` // Amount in cents
 }
 
-// UserBalance represents the net balance for a user in a group.
+// UserBalance represents the net balance for a user in a group, in the group's base currency.
 type UserBalance struct {
 	UserID  uint  `json:"user_id"`
 	Balance int64 `json:"balance"` // Positive means owed money, negative means owes money
 }
+
+// Payment represents a recorded settle-up payment from one user to another within a group.
+// Unlike Settlement, payments are persisted and are folded into the group's net balances.
+type Payment struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	GroupID    uint      `json:"group_id" gorm:"not null;index"`
+	FromUserID uint      `json:"from_user_id" gorm:"not null;index"`
+	ToUserID   uint      `json:"to_user_id" gorm:"not null;index"`
+	Amount     int64     `json:"amount" gorm:"not null"` // Amount in cents of the group's base currency
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RecurrenceFrequency is the unit of time between occurrences of a recurring expense.
+type RecurrenceFrequency string
+
+const (
+	FrequencyDaily   RecurrenceFrequency = "daily"
+	FrequencyWeekly  RecurrenceFrequency = "weekly"
+	FrequencyMonthly RecurrenceFrequency = "monthly"
+	FrequencyYearly  RecurrenceFrequency = "yearly"
+)
+
+// RecurringExpense is a template for an expense that repeats, like rent or a subscription.
+// Occurrences are materialised into regular expenses by the background scheduler: the expense
+// repeats every Interval units of Frequency starting at StartDate, until EndDate if set.
+// For monthly and yearly templates DayOfMonth pins the day, clamped to shorter months.
+type RecurringExpense struct {
+	ID          uint                `json:"id" gorm:"primaryKey"`
+	GroupID     uint                `json:"group_id" gorm:"not null;index"`
+	CreatedByID uint                `json:"created_by_id" gorm:"not null"`
+	PayerID     uint                `json:"payer_id" gorm:"not null"`
+	Amount      int64               `json:"amount" gorm:"not null"` // Amount in cents
+	Description string              `json:"description" gorm:"not null"`
+	Category    string              `json:"category"`
+	Currency    string              `json:"currency" gorm:"size:3"` // Empty means the group's base currency
+	SplitType   SplitType           `json:"split_type" gorm:"not null;default:exact"`
+	Frequency   RecurrenceFrequency `json:"frequency" gorm:"not null"`
+	Interval    int                 `json:"interval" gorm:"not null;default:1"`
+	DayOfMonth  int                 `json:"day_of_month,omitempty"`
+	StartDate   time.Time           `json:"start_date" gorm:"not null"`
+	EndDate     *time.Time          `json:"end_date,omitempty"`
+	NextRunAt   time.Time           `json:"next_run_at" gorm:"not null;index"`
+	Active      bool                `json:"active" gorm:"not null;default:true"`
+	CreatedAt   time.Time           `json:"created_at" gorm:"autoCreateTime"`
+
+	// Relationships
+	Splits []RecurringExpenseSplit `json:"splits,omitempty" gorm:"foreignKey:RecurringExpenseID"`
+}
+
+// RecurringExpenseSplit stores one participant of a recurring expense as it was requested,
+// so the split is recomputed for every occurrence exactly like a manually entered expense.
+type RecurringExpenseSplit struct {
+	ID                 uint  `json:"id" gorm:"primaryKey"`
+	RecurringExpenseID uint  `json:"recurring_expense_id" gorm:"not null;index"`
+	UserID             uint  `json:"user_id" gorm:"not null"`
+	Amount             int64 `json:"amount"`     // exact split, in cents
+	Percentage         int64 `json:"percentage"` // percentage split, in basis points
+	Shares             int64 `json:"shares"`     // shares split, relative weight
+}
+
+// RecurringExpenseOccurrence records that a given occurrence of a recurring expense has been
+// materialised, which makes the scheduler idempotent across restarts and multiple instances.
+type RecurringExpenseOccurrence struct {
+	RecurringExpenseID uint      `json:"recurring_expense_id" gorm:"primaryKey"`
+	OccurrenceDate     time.Time `json:"occurrence_date" gorm:"primaryKey"`
+	ExpenseID          *uint     `json:"expense_id"`
+	CreatedAt          time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// GroupContribution is the part of a cross-group balance that comes from a single group.
+type GroupContribution struct {
+	GroupID    uint   `json:"group_id"`
+	GroupTitle string `json:"group_title"`
+	Amount     int64  `json:"amount"` // Positive means the counterparty owes the user
+}
+
+// CounterpartyBalance is what a user and one other person owe each other across all shared groups.
+type CounterpartyBalance struct {
+	UserID   uint                `json:"user_id"`
+	Currency string              `json:"currency"`
+	Balance  int64               `json:"balance"` // Positive means the counterparty owes the user
+	Groups   []GroupContribution `json:"groups"`
+}
+
+// UserSettlementSummary simplifies a user's debts across every group they belong to.
+// It is unpersisted, and only used for returning cross-group settlement results.
+type UserSettlementSummary struct {
+	UserID         uint                  `json:"user_id"`
+	Settlements    []Settlement          `json:"settlements"`
+	Counterparties []CounterpartyBalance `json:"counterparties"`
+}
+
+// ActivityType is the kind of event shown in a group's activity feed.
+type ActivityType string
+
+const (
+	ActivityExpenseAdded    ActivityType = "expense_added"
+	ActivityExpenseEdited   ActivityType = "expense_edited"
+	ActivityExpenseDeleted  ActivityType = "expense_deleted"
+	ActivityMemberJoined    ActivityType = "member_joined"
+	ActivityMemberLeft      ActivityType = "member_left"
+	ActivityPaymentRecorded ActivityType = "payment_recorded"
+	ActivityGroupRenamed    ActivityType = "group_renamed"
+)
+
+// Activity is one event in a group's activity feed. Activities are append-only: they are never
+// updated or deleted, and their IDs increase in the order they happened. Which of the optional
+// references is set depends on the type: ExpenseID for expense events, PaymentID for payments,
+// and UserID for the member who joined or left.
+type Activity struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	GroupID   uint         `json:"group_id" gorm:"not null;index"`
+	Type      ActivityType `json:"type" gorm:"not null"`
+	ActorID   uint         `json:"actor_id" gorm:"not null"`
+	UserID    *uint        `json:"user_id,omitempty"`
+	ExpenseID *uint        `json:"expense_id,omitempty"`
+	PaymentID *uint        `json:"payment_id,omitempty"`
+	Data      ActivityData `json:"data" gorm:"type:jsonb;serializer:json;not null"`
+	CreatedAt time.Time    `json:"created_at" gorm:"autoCreateTime"`
+
+	// Unread is only populated in feeds, relative to the reader's read markers
+	Unread bool `json:"unread" gorm:"-"`
+}
+
+// ActivityData describes an activity at the time it happened, so the feed still reads
+// correctly after the expense is edited or the group renamed again.
+type ActivityData struct {
+	Description string `json:"description,omitempty"`
+	Amount      int64  `json:"amount,omitempty"` // In cents of Currency
+	Currency    string `json:"currency,omitempty"`
+	OldTitle    string `json:"old_title,omitempty"` // group_renamed only
+	NewTitle    string `json:"new_title,omitempty"` // group_renamed only
+}
+
+// ActivityRead is how far a user has read a group's activity feed. Every activity with a
+// higher ID, other than the user's own, is unread.
+type ActivityRead struct {
+	UserID     uint      `json:"user_id" gorm:"primaryKey"`
+	GroupID    uint      `json:"group_id" gorm:"primaryKey"`
+	LastReadID uint      `json:"last_read_id" gorm:"not null"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ActivityFeed is one page of activities, newest first. NextCursor is empty on the last page;
+// UnreadCount covers the whole feed, not just this page.
+type ActivityFeed struct {
+	Activities  []Activity `json:"activities"`
+	NextCursor  string     `json:"next_cursor,omitempty"`
+	UnreadCount int64      `json:"unread_count"`
+}
+
+// WebhookEvents are the activity types a webhook can subscribe to.
+var WebhookEvents = []ActivityType{ActivityExpenseAdded, ActivityPaymentRecorded}
+
+// Webhook sends a group's events to an external URL. Every request is signed with Secret,
+// which is only returned once, when the webhook is created. Events lists the activity types
+// to deliver; an empty list means all of WebhookEvents.
+type Webhook struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	GroupID     uint           `json:"group_id" gorm:"not null;index"`
+	URL         string         `json:"url" gorm:"not null"`
+	Secret      string         `json:"-" gorm:"not null"`
+	Events      []ActivityType `json:"events" gorm:"type:jsonb;serializer:json;not null"`
+	CreatedByID uint           `json:"created_by_id" gorm:"not null"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// WebhookDeliveryStatus is where a delivery stands in the retry queue.
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"   // Waiting for its next attempt
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded" // The endpoint answered with a 2xx
+	DeliveryFailed    WebhookDeliveryStatus = "failed"    // Gave up after the last attempt
+)
+
+// WebhookDelivery is one event queued for one webhook. Pending deliveries are retried with
+// exponential backoff until they succeed or run out of attempts, and the row doubles as the
+// delivery log.
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	WebhookID      uint                  `json:"webhook_id" gorm:"not null;index"`
+	ActivityID     uint                  `json:"activity_id" gorm:"not null"`
+	Event          ActivityType          `json:"event" gorm:"not null"`
+	Payload        string                `json:"payload" gorm:"type:jsonb;not null"` // The exact body that is signed and sent
+	Status         WebhookDeliveryStatus `json:"status" gorm:"not null;default:pending"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"not null"`
+	ResponseStatus int                   `json:"response_status,omitempty"` // HTTP status of the last attempt
+	LastError      string                `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// NotificationPreferences are a user's email opt-outs. Users who never changed them get
+// every email.
+type NotificationPreferences struct {
+	UserID          uint      `json:"user_id" gorm:"primaryKey"`
+	ExpenseAdded    bool      `json:"expense_added" gorm:"not null"`    // Someone added them to an expense
+	PaymentReceived bool      `json:"payment_received" gorm:"not null"` // Someone recorded a payment to them
+	WeeklyDigest    bool      `json:"weekly_digest" gorm:"not null"`    // Their balance in each group, every Monday
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// WeeklyDigest records that a user's digest for a week was sent, so it goes out only once.
+type WeeklyDigest struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	WeekStart time.Time `json:"week_start" gorm:"primaryKey;type:date"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
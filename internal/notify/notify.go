@@ -0,0 +1,46 @@
+// Package notify renders and sends email notifications.
+package notify
+
+import (
+	"context"
+	"log/slog"
+
+	"expense-tracker/internal/config"
+)
+
+// Message is a plain-text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Open returns the sender selected in the configuration. Without an SMTP host, messages are
+// only logged, so development setups don't need a mail server.
+func Open(cfg *config.AppConfig, logger *slog.Logger) (Sender, error) {
+	if cfg.SMTPHost == "" {
+		return &logSender{logger: logger}, nil
+	}
+	return NewSMTPSender(SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	})
+}
+
+// logSender stands in for a mail server by logging each message.
+type logSender struct {
+	logger *slog.Logger
+}
+
+func (s *logSender) Send(ctx context.Context, msg Message) error {
+	s.logger.Info("email not sent, SMTP is not configured", slog.String("to", msg.To), slog.String("subject", msg.Subject))
+	return nil
+}
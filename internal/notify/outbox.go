@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// outboxSize messages can wait to be sent; beyond that new ones are dropped.
+const outboxSize = 256
+
+// Outbox sends messages in the background, so that requests never wait on the mail server.
+// Notifications are best effort: a message that can't be sent is logged and dropped.
+type Outbox struct {
+	sender Sender
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	queue  chan Message
+	closed bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewOutbox(sender Sender, logger *slog.Logger) *Outbox {
+	return &Outbox{sender: sender, logger: logger, queue: make(chan Message, outboxSize)}
+}
+
+// Start launches the goroutine that sends queued messages.
+func (o *Outbox) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancel = cancel
+	o.done = make(chan struct{})
+
+	go func() {
+		defer close(o.done)
+		dropped := 0
+		for msg := range o.queue {
+			// Once Stop gives up, the rest of the queue is drained without sending
+			if ctx.Err() != nil {
+				dropped++
+				continue
+			}
+			if err := o.sender.Send(ctx, msg); err != nil {
+				o.logger.Error("failed to send email", slog.String("to", msg.To), slog.String("subject", msg.Subject), slog.String("error", err.Error()))
+			}
+		}
+		if dropped > 0 {
+			o.logger.Warn("emails dropped on shutdown", slog.Int("count", dropped))
+		}
+	}()
+}
+
+// Enqueue queues a message without blocking. It reports false when the message was dropped
+// because the queue is full or the outbox has stopped.
+func (o *Outbox) Enqueue(msg Message) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return false
+	}
+	select {
+	case o.queue <- msg:
+		return true
+	default:
+		o.logger.Warn("email dropped, outbox is full", slog.String("to", msg.To), slog.String("subject", msg.Subject))
+		return false
+	}
+}
+
+// Stop stops accepting messages and blocks until the queued ones have been sent. When ctx ends
+// first, the message being sent is abandoned and the ones still queued are dropped.
+func (o *Outbox) Stop(ctx context.Context) {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return
+	}
+	o.closed = true
+	close(o.queue)
+	o.mu.Unlock()
+
+	if o.done == nil {
+		return
+	}
+	defer o.cancel()
+	select {
+	case <-o.done:
+	case <-ctx.Done():
+		o.cancel()
+		<-o.done
+	}
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingSender sends nothing until its context ends.
+type blockingSender struct {
+	calls atomic.Int32
+}
+
+func (s *blockingSender) Send(ctx context.Context, msg Message) error {
+	s.calls.Add(1)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestOutboxStopDropsQueueAtDeadline(t *testing.T) {
+	sender := &blockingSender{}
+	outbox := NewOutbox(sender, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	outbox.Start()
+	for i := 0; i < 3; i++ {
+		if !outbox.Enqueue(Message{To: "a@example.com", Subject: "Hi"}) {
+			t.Fatal("message dropped before Stop")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		outbox.Stop(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return after its deadline")
+	}
+	if n := sender.calls.Load(); n > 1 {
+		t.Errorf("sent %d messages, want at most the one in flight", n)
+	}
+	if outbox.Enqueue(Message{To: "a@example.com"}) {
+		t.Error("message accepted after Stop")
+	}
+}
@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// fakeSMTP is a minimal SMTP server on loopback that accepts one session and records it.
+// It offers neither STARTTLS nor AUTH, like a local mail catcher.
+type fakeSMTP struct {
+	addr     string
+	commands []string
+	data     []byte
+	done     chan struct{}
+}
+
+// newFakeSMTP starts the server. rcptReply is the reply to RCPT TO, e.g. "250 OK".
+func newFakeSMTP(t *testing.T, rcptReply string) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := &fakeSMTP{addr: ln.Addr().String(), done: make(chan struct{})}
+	go func() {
+		defer close(srv.done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		srv.serve(textproto.NewConn(conn), rcptReply)
+	}()
+	return srv
+}
+
+func (s *fakeSMTP) serve(c *textproto.Conn, rcptReply string) {
+	c.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		s.commands = append(s.commands, line)
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO":
+			c.PrintfLine("250 localhost")
+		case "MAIL":
+			c.PrintfLine("250 OK")
+		case "RCPT":
+			c.PrintfLine("%s", rcptReply)
+		case "DATA":
+			c.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			if s.data, err = c.ReadDotBytes(); err != nil {
+				return
+			}
+			c.PrintfLine("250 OK")
+		case "QUIT":
+			c.PrintfLine("221 Bye")
+			return
+		default:
+			c.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func newTestSMTPSender(t *testing.T, addr string) *SMTPSender {
+	host, port, _ := net.SplitHostPort(addr)
+	sender, err := NewSMTPSender(SMTPConfig{Host: host, Port: port, From: "Expense Tracker <noreply@example.com>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sender
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	srv := newFakeSMTP(t, "250 OK")
+	sender := newTestSMTPSender(t, srv.addr)
+
+	// The subject carries user-supplied text that tries to smuggle in a header
+	subject := "Bob added you to \"Dinner\r\nBcc: everyone@example.com\" in Café trip"
+	body := "Hi Alice,\n\nTotal: 1=2 €\n" + strings.Repeat("a long line ", 20) + "\n.\nEnd\n"
+	err := sender.Send(context.Background(), Message{To: "Alice <alice@example.com>", Subject: subject, Body: body})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	<-srv.done
+
+	want := []string{"EHLO", "MAIL FROM:<noreply@example.com>", "RCPT TO:<alice@example.com>", "DATA", "QUIT"}
+	if len(srv.commands) != len(want) {
+		t.Fatalf("commands %q, want %q", srv.commands, want)
+	}
+	for i, cmd := range srv.commands {
+		if !strings.HasPrefix(cmd, want[i]) {
+			t.Errorf("command %d is %q, want %q", i, cmd, want[i])
+		}
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(srv.data)))
+	if err != nil {
+		t.Fatalf("parsing the message: %v\n%s", err, srv.data)
+	}
+	if _, ok := msg.Header["Bcc"]; ok {
+		t.Error("the subject injected a Bcc header")
+	}
+	rawSubject := msg.Header.Get("Subject")
+	if !strings.HasPrefix(rawSubject, "=?utf-8?q?") {
+		t.Errorf("subject %q is not Q-encoded", rawSubject)
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(rawSubject)
+	if err != nil || decoded != subject {
+		t.Errorf("subject decodes to %q (%v), want %q", decoded, err, subject)
+	}
+	if got := msg.Header.Get("To"); got != `"Alice" <alice@example.com>` {
+		t.Errorf("To %q", got)
+	}
+
+	if got := msg.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Fatalf("Content-Transfer-Encoding %q", got)
+	}
+	raw, _ := io.ReadAll(msg.Body)
+	for _, line := range strings.Split(string(raw), "\n") {
+		if len(strings.TrimSuffix(line, "\r")) > 76 {
+			t.Errorf("body line longer than 76 characters: %q", line)
+		}
+	}
+	if !strings.Contains(string(raw), "1=3D2 =E2=82=AC") {
+		t.Errorf("body is not quoted-printable:\n%s", raw)
+	}
+	text, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.ReplaceAll(string(text), "\r\n", "\n"); got != body {
+		t.Errorf("body decodes to %q, want %q", got, body)
+	}
+}
+
+func TestSMTPSenderRejectedRecipient(t *testing.T) {
+	srv := newFakeSMTP(t, "550 No such user")
+	sender := newTestSMTPSender(t, srv.addr)
+
+	err := sender.Send(context.Background(), Message{To: "nobody@example.com", Subject: "Hi", Body: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), "No such user") {
+		t.Fatalf("Send to a rejected recipient: got %v", err)
+	}
+}
+
+func TestSMTPSenderInvalidRecipient(t *testing.T) {
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: "1", From: "noreply@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rejected before anything is dialled
+	if err := sender.Send(context.Background(), Message{To: "not an address\r\nBcc: x@example.com"}); err == nil {
+		t.Fatal("expected an invalid address error")
+	}
+}
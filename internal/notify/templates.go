@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"expense-tracker/internal/currency"
+)
+
+const footer = `
+--
+You're getting this email because you're a member of a group on Expense Tracker.
+You can turn these emails off in your notification settings.
+`
+
+// Each email is a pair of templates, "<name>.subject" and "<name>.body".
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"money": func(amount int64, code string) string { return currency.FormatAmount(amount, code) + " " + code },
+	"neg":   func(amount int64) int64 { return -amount },
+}).Parse(`
+{{define "expense_added.subject"}}{{.ActorName}} added you to "{{.Description}}" in {{.GroupTitle}}{{end}}
+{{define "expense_added.body"}}Hi {{.RecipientName}},
+
+{{.ActorName}} added an expense to {{.GroupTitle}} that you're part of:
+
+  {{.Description}}
+  Total:      {{money .Amount .Currency}}
+  Your share: {{money .Share .Currency}}
+{{end}}
+
+{{define "payment_received.subject"}}{{.ActorName}} recorded a payment of {{money .Amount .Currency}} to you{{end}}
+{{define "payment_received.body"}}Hi {{.RecipientName}},
+
+{{.ActorName}} recorded a payment of {{money .Amount .Currency}} to you in {{.GroupTitle}}.
+{{- if .Note}}
+
+  "{{.Note}}"
+{{- end}}
+
+Your balance in the group now reflects it. If you didn't receive this money, let {{.ActorName}} know.
+{{end}}
+
+{{define "weekly_digest.subject"}}Your weekly balance summary{{end}}
+{{define "weekly_digest.body"}}Hi {{.RecipientName}},
+
+Here's where you stand in your groups:
+{{range .Groups}}
+  {{.Title}}: {{if gt .Balance 0}}you are owed {{money .Balance .Currency}}{{else}}you owe {{money (neg .Balance) .Currency}}{{end}}
+{{- end}}
+
+Open a group's settlements to see the quickest way to settle up.
+{{end}}
+`))
+
+// ExpenseAddedData fills the email to someone who was added to an expense's split.
+type ExpenseAddedData struct {
+	RecipientName string
+	ActorName     string
+	GroupTitle    string
+	Description   string
+	Amount        int64 // Expense total, in minor units of Currency
+	Share         int64 // The recipient's part of it
+	Currency      string
+}
+
+// PaymentReceivedData fills the email to the receiver of a recorded payment.
+type PaymentReceivedData struct {
+	RecipientName string
+	ActorName     string // Whoever recorded the payment
+	GroupTitle    string
+	Amount        int64
+	Currency      string
+	Note          string
+}
+
+// DigestData fills the weekly digest. Groups only lists the groups the recipient isn't
+// settled up in.
+type DigestData struct {
+	RecipientName string
+	Groups        []DigestGroup
+}
+
+type DigestGroup struct {
+	Title    string
+	Balance  int64 // Positive when the recipient is owed money
+	Currency string
+}
+
+func ExpenseAddedEmail(to string, data ExpenseAddedData) (Message, error) {
+	return render("expense_added", to, data)
+}
+
+func PaymentReceivedEmail(to string, data PaymentReceivedData) (Message, error) {
+	return render("payment_received", to, data)
+}
+
+func WeeklyDigestEmail(to string, data DigestData) (Message, error) {
+	return render("weekly_digest", to, data)
+}
+
+func render(name string, to string, data any) (Message, error) {
+	var subject, body bytes.Buffer
+	if err := templates.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := templates.ExecuteTemplate(&body, name+".body", data); err != nil {
+		return Message{}, err
+	}
+	body.WriteString(footer)
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    body.String(),
+	}, nil
+}
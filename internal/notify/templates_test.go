@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpenseAddedEmail(t *testing.T) {
+	msg, err := ExpenseAddedEmail("alice@example.com", ExpenseAddedData{
+		RecipientName: "Alice",
+		ActorName:     "Bob",
+		GroupTitle:    "Lisbon",
+		Description:   "Dinner",
+		Amount:        9000,
+		Share:         3000,
+		Currency:      "EUR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.To != "alice@example.com" {
+		t.Errorf("To %q", msg.To)
+	}
+	if want := `Bob added you to "Dinner" in Lisbon`; msg.Subject != want {
+		t.Errorf("Subject %q, want %q", msg.Subject, want)
+	}
+	for _, want := range []string{"Hi Alice,", "Total:      90.00 EUR", "Your share: 30.00 EUR", "notification settings"} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("body is missing %q:\n%s", want, msg.Body)
+		}
+	}
+}
+
+func TestPaymentReceivedEmail(t *testing.T) {
+	data := PaymentReceivedData{
+		RecipientName: "Alice",
+		ActorName:     "Bob",
+		GroupTitle:    "Flat",
+		Amount:        2500,
+		Currency:      "JPY",
+	}
+	msg, err := PaymentReceivedEmail("alice@example.com", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Bob recorded a payment of 2500 JPY to you"; msg.Subject != want {
+		t.Errorf("Subject %q, want %q", msg.Subject, want)
+	}
+	if !strings.Contains(msg.Body, "recorded a payment of 2500 JPY to you in Flat.") {
+		t.Errorf("unexpected body:\n%s", msg.Body)
+	}
+	if strings.Contains(msg.Body, `"`) {
+		t.Errorf("a payment without a note still quotes one:\n%s", msg.Body)
+	}
+
+	data.Note = "Rent for March"
+	msg, err = PaymentReceivedEmail("alice@example.com", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg.Body, `"Rent for March"`) {
+		t.Errorf("the note is missing:\n%s", msg.Body)
+	}
+}
+
+func TestWeeklyDigestEmail(t *testing.T) {
+	msg, err := WeeklyDigestEmail("alice@example.com", DigestData{
+		RecipientName: "Alice",
+		Groups: []DigestGroup{
+			{Title: "Lisbon", Balance: 1250, Currency: "EUR"},
+			{Title: "Flat", Balance: -4000, Currency: "USD"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Subject != "Your weekly balance summary" {
+		t.Errorf("Subject %q", msg.Subject)
+	}
+	for _, want := range []string{"Lisbon: you are owed 12.50 EUR", "Flat: you owe 40.00 USD"} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("body is missing %q:\n%s", want, msg.Body)
+		}
+	}
+	if strings.Contains(msg.Body, "-40.00") {
+		t.Errorf("a debt is shown as a negative amount:\n%s", msg.Body)
+	}
+}
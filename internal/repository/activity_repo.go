@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"gorm.io/gorm/clause"
+
+	"expense-tracker/internal/model"
+)
+
+// ActivityQuery pages backwards through the activities of one or more groups.
+type ActivityQuery struct {
+	GroupIDs []uint
+	Cursor   string // NextCursor of the previous page
+	Limit    int
+}
+
+// Normalize clamps the page size.
+func (q *ActivityQuery) Normalize() {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		q.Limit = MaxPageSize
+	}
+}
+
+// ActivityRepository stores the append-only activity feed and each user's read markers.
+type ActivityRepository interface {
+	CreateActivity(ctx context.Context, activity *model.Activity) error
+	// ListActivities returns one page of activities, newest first, and the cursor of the next page.
+	ListActivities(ctx context.Context, query ActivityQuery) ([]model.Activity, string, error)
+	// GetReadMarkers returns the last read activity ID per group; groups never read are omitted.
+	GetReadMarkers(ctx context.Context, userID uint, groupIDs []uint) (map[uint]uint, error)
+	// MarkRead moves the user's read marker for the group forward to activityID. A marker never
+	// moves backwards.
+	MarkRead(ctx context.Context, userID uint, groupID uint, activityID uint) error
+	// CountUnread counts the activities in the groups after the user's read markers, leaving
+	// out the user's own.
+	CountUnread(ctx context.Context, userID uint, groupIDs []uint) (int64, error)
+	// GetLatestActivityID returns the ID of the group's newest activity, or 0 if it has none.
+	GetLatestActivityID(ctx context.Context, groupID uint) (uint, error)
+}
+
+type activityRepository struct {
+	db *DB
+}
+
+func NewActivityRepository(db *DB) ActivityRepository {
+	return &activityRepository{db: db}
+}
+
+func (r *activityRepository) CreateActivity(ctx context.Context, activity *model.Activity) error {
+	return r.db.WithContext(ctx).Create(activity).Error
+}
+
+// ListActivities uses keyset pagination on the ID, which increases with every activity, so
+// pages stay stable while new activities are appended.
+func (r *activityRepository) ListActivities(ctx context.Context, query ActivityQuery) ([]model.Activity, string, error) {
+	query.Normalize()
+	if len(query.GroupIDs) == 0 {
+		return []model.Activity{}, "", nil
+	}
+
+	db := r.db.WithContext(ctx).Where("group_id IN ?", query.GroupIDs)
+	if query.Cursor != "" {
+		before, err := strconv.ParseUint(query.Cursor, 10, 64)
+		if err != nil || before == 0 {
+			return nil, "", ErrInvalidCursor
+		}
+		db = db.Where("id < ?", before)
+	}
+
+	var activities []model.Activity
+	if err := db.Order("id DESC").Limit(query.Limit + 1).Find(&activities).Error; err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(activities) > query.Limit {
+		activities = activities[:query.Limit]
+		next = strconv.FormatUint(uint64(activities[query.Limit-1].ID), 10)
+	}
+	if activities == nil {
+		activities = []model.Activity{}
+	}
+	return activities, next, nil
+}
+
+func (r *activityRepository) GetReadMarkers(ctx context.Context, userID uint, groupIDs []uint) (map[uint]uint, error) {
+	markers := make(map[uint]uint)
+	if len(groupIDs) == 0 {
+		return markers, nil
+	}
+
+	var reads []model.ActivityRead
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND group_id IN ?", userID, groupIDs).
+		Find(&reads).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, read := range reads {
+		markers[read.GroupID] = read.LastReadID
+	}
+	return markers, nil
+}
+
+func (r *activityRepository) MarkRead(ctx context.Context, userID uint, groupID uint, activityID uint) error {
+	read := model.ActivityRead{UserID: userID, GroupID: groupID, LastReadID: activityID}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "group_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_id": clause.Expr{SQL: "GREATEST(activity_reads.last_read_id, EXCLUDED.last_read_id)"},
+			"updated_at":   clause.Expr{SQL: "EXCLUDED.updated_at"},
+		}),
+	}).Create(&read).Error
+}
+
+func (r *activityRepository) CountUnread(ctx context.Context, userID uint, groupIDs []uint) (int64, error) {
+	if len(groupIDs) == 0 {
+		return 0, nil
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("activities AS a").
+		Joins("LEFT JOIN activity_reads AS r ON r.group_id = a.group_id AND r.user_id = ?", userID).
+		Where("a.group_id IN ? AND a.actor_id <> ? AND a.id > COALESCE(r.last_read_id, 0)", groupIDs, userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *activityRepository) GetLatestActivityID(ctx context.Context, groupID uint) (uint, error) {
+	var id uint
+	err := r.db.WithContext(ctx).Model(&model.Activity{}).
+		Where("group_id = ?", groupID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Kinds of amounts returned by GetSpendingBuckets.
+const (
+	BucketPaid     = "paid"     // Expenses the user paid for
+	BucketConsumed = "consumed" // The user's splits
+	BucketSent     = "sent"     // Payments the user made
+	BucketReceived = "received" // Payments the user received
+)
+
+// SpendingBucket is the total of one kind of amount for one user in one group and time bucket.
+// Expense amounts are summed per currency and exchange rate so the caller can convert them;
+// payments are always in the group's base currency and carry no currency of their own.
+type SpendingBucket struct {
+	GroupID      uint
+	UserID       uint
+	Period       time.Time
+	Kind         string
+	Currency     string
+	ExchangeRate float64
+	Amount       int64
+}
+
+// SpendingQuery selects the buckets to aggregate. UserID 0 means every user; To is exclusive.
+// Interval is passed to date_trunc and must be one of day, week or month.
+type SpendingQuery struct {
+	GroupIDs []uint
+	UserID   uint
+	Interval string
+	To       *time.Time
+}
+
+type AnalyticsRepository interface {
+	GetSpendingBuckets(ctx context.Context, query SpendingQuery) ([]SpendingBucket, error)
+}
+
+type analyticsRepository struct {
+	db *DB
+}
+
+func NewAnalyticsRepository(db *DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// spendingBucketsSQL aggregates everything that moves a user's balance into UTC time buckets,
+// so only one row per user, bucket and rate leaves the database.
+const spendingBucketsSQL = `
+SELECT e.group_id, p.user_id, date_trunc(@interval, e.created_at AT TIME ZONE 'UTC') AS period,
+       'paid' AS kind, e.currency, e.exchange_rate, SUM(p.amount)::bigint AS amount
+FROM expense_payers p
+JOIN expenses e ON e.id = p.expense_id
+WHERE e.group_id IN @groups AND (@user = 0 OR p.user_id = @user)
+  AND (CAST(@to AS timestamptz) IS NULL OR e.created_at < @to)
+GROUP BY 1, 2, 3, 5, 6
+UNION ALL
+SELECT e.group_id, s.user_id, date_trunc(@interval, e.created_at AT TIME ZONE 'UTC'),
+       'consumed', e.currency, e.exchange_rate, SUM(s.amount)::bigint
+FROM expense_splits s
+JOIN expenses e ON e.id = s.expense_id
+WHERE e.group_id IN @groups AND (@user = 0 OR s.user_id = @user)
+  AND (CAST(@to AS timestamptz) IS NULL OR e.created_at < @to)
+GROUP BY 1, 2, 3, 5, 6
+UNION ALL
+SELECT p.group_id, p.from_user_id, date_trunc(@interval, p.created_at AT TIME ZONE 'UTC'),
+       'sent', '', 1.0, SUM(p.amount)::bigint
+FROM payments p
+WHERE p.group_id IN @groups AND (@user = 0 OR p.from_user_id = @user)
+  AND (CAST(@to AS timestamptz) IS NULL OR p.created_at < @to)
+GROUP BY 1, 2, 3
+UNION ALL
+SELECT p.group_id, p.to_user_id, date_trunc(@interval, p.created_at AT TIME ZONE 'UTC'),
+       'received', '', 1.0, SUM(p.amount)::bigint
+FROM payments p
+WHERE p.group_id IN @groups AND (@user = 0 OR p.to_user_id = @user)
+  AND (CAST(@to AS timestamptz) IS NULL OR p.created_at < @to)
+GROUP BY 1, 2, 3
+ORDER BY period`
+
+func (r *analyticsRepository) GetSpendingBuckets(ctx context.Context, query SpendingQuery) ([]SpendingBucket, error) {
+	if len(query.GroupIDs) == 0 {
+		return nil, nil
+	}
+
+	var buckets []SpendingBucket
+	err := r.db.WithContext(ctx).Raw(spendingBucketsSQL,
+		sql.Named("interval", query.Interval),
+		sql.Named("groups", query.GroupIDs),
+		sql.Named("user", query.UserID),
+		sql.Named("to", query.To),
+	).Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
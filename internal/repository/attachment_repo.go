@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"expense-tracker/internal/model"
+)
+
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, attachment *model.Attachment) error
+	GetAttachmentByID(ctx context.Context, id uint) (*model.Attachment, error)
+	GetAttachmentsByExpenseID(ctx context.Context, expenseID uint) ([]model.Attachment, error)
+	DeleteAttachment(ctx context.Context, id uint) error
+}
+
+type attachmentRepository struct {
+	db *DB
+}
+
+func NewAttachmentRepository(db *DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (r *attachmentRepository) CreateAttachment(ctx context.Context, attachment *model.Attachment) error {
+	return r.db.WithContext(ctx).Create(attachment).Error
+}
+
+func (r *attachmentRepository) GetAttachmentByID(ctx context.Context, id uint) (*model.Attachment, error) {
+	var attachment model.Attachment
+	if err := r.db.WithContext(ctx).First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *attachmentRepository) GetAttachmentsByExpenseID(ctx context.Context, expenseID uint) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	if err := r.db.WithContext(ctx).Where("expense_id = ?", expenseID).Order("created_at, id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *attachmentRepository) DeleteAttachment(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Attachment{}, id).Error
+}
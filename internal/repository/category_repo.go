@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"expense-tracker/internal/model"
+)
+
+type CategoryRepository interface {
+	CreateCategory(ctx context.Context, category *model.Category) error
+	GetCategoriesByGroupID(ctx context.Context, groupID uint) ([]model.Category, error)
+	CategoryExists(ctx context.Context, groupID uint, name string) (bool, error)
+}
+
+type categoryRepository struct {
+	db *DB
+}
+
+func NewCategoryRepository(db *DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func (r *categoryRepository) CreateCategory(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *categoryRepository) GetCategoriesByGroupID(ctx context.Context, groupID uint) ([]model.Category, error) {
+	var categories []model.Category
+	if err := r.db.WithContext(ctx).Where("group_id = ?", groupID).Order("name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *categoryRepository) CategoryExists(ctx context.Context, groupID uint, name string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Category{}).
+		Where("group_id = ? AND name = ?", groupID, name).
+		Count(&count).Error
+	return count > 0, err
+}
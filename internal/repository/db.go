@@ -19,6 +19,8 @@ type DB struct {
 func NewDB(cfg *config.AppConfig) (*DB, error) {
 	db, err := gorm.Open(postgres.Open(cfg.GetDSN()), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// Unique violations come back as gorm.ErrDuplicatedKey, so services can tell them apart
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, err
@@ -31,3 +33,53 @@ func NewDB(cfg *config.AppConfig) (*DB, error) {
 func (db *DB) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
 	return db.WithContext(ctx).Transaction(fn)
 }
+
+type txKey struct{}
+
+// txState is the transaction InTx put in a context, with the work waiting for it to commit.
+type txState struct {
+	tx          *gorm.DB
+	afterCommit []func(ctx context.Context)
+}
+
+// WithContext starts a session for ctx. When ctx comes from InTx the session runs inside that
+// transaction, so every repository takes part in it without being told.
+func (db *DB) WithContext(ctx context.Context) *gorm.DB {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx.WithContext(ctx)
+	}
+	return db.DB.WithContext(ctx)
+}
+
+// InTx runs fn in a transaction that repository calls made with fn's context take part in.
+// Called with a context that is already in a transaction, fn runs in a savepoint of it.
+func (db *DB) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	state := &txState{}
+	err := db.Transaction(ctx, func(tx *gorm.DB) error {
+		state.tx = tx
+		return fn(context.WithValue(ctx, txKey{}, state))
+	})
+	if err != nil {
+		return err
+	}
+
+	// A savepoint's work waits for the transaction around it
+	if parent, ok := ctx.Value(txKey{}).(*txState); ok {
+		parent.afterCommit = append(parent.afterCommit, state.afterCommit...)
+		return nil
+	}
+	for _, f := range state.afterCommit {
+		f(ctx)
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction ctx is in has committed, with a context outside of
+// it; fn is dropped if the transaction rolls back. Outside a transaction fn runs straight away.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn(ctx)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/model"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+)
+
+// ExpenseSort is a stable sort order for expense listings. Ties are always broken by ID.
+type ExpenseSort string
+
+const (
+	SortDateDesc   ExpenseSort = "date_desc" // Newest first (default)
+	SortDateAsc    ExpenseSort = "date_asc"
+	SortAmountDesc ExpenseSort = "amount_desc"
+	SortAmountAsc  ExpenseSort = "amount_asc"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ExpenseQuery filters, sorts and paginates a group's expenses. Zero values disable a filter.
+type ExpenseQuery struct {
+	GroupID       uint
+	PayerID       uint
+	ParticipantID uint // Only expenses this user has a split in
+	From          *time.Time
+	To            *time.Time
+	MinAmount     *int64 // Amounts are in minor units of BaseCurrency
+	MaxAmount     *int64
+	BaseCurrency  string // The group's; amount filters and sorts convert every expense into it
+	Search        string // Case-insensitive substring of the description
+	Sort          ExpenseSort
+	Cursor        string // NextCursor of the previous page
+	Limit         int
+}
+
+// Normalize fills in defaults and clamps the page size.
+func (q *ExpenseQuery) Normalize() {
+	if q.Sort == "" {
+		q.Sort = SortDateDesc
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		q.Limit = MaxPageSize
+	}
+	q.Search = strings.TrimSpace(q.Search)
+}
+
+// expenseCursor is the position after the last expense of a page, in terms of the sort key.
+// It records the sort it was made for, since a position in one order means nothing in another.
+type expenseCursor struct {
+	Sort      ExpenseSort `json:"s"`
+	CreatedAt time.Time   `json:"c,omitempty"`
+	Amount    int64       `json:"a,omitempty"`
+	ID        uint        `json:"i"`
+}
+
+func encodeCursor(c expenseCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor, rejecting one that was made for a different sort.
+func decodeCursor(s string, sort ExpenseSort) (*expenseCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c expenseCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 || c.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ExpensePage is one page of an expense listing. NextCursor is empty on the last page.
+type ExpensePage struct {
+	Expenses   []model.Expense `json:"expenses"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
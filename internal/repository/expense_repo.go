@@ -2,14 +2,52 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"expense-tracker/internal/currency"
 	"expense-tracker/internal/model"
 )
 
+// ExpenseRepository stores expenses. Every create, update and delete also appends a version
+// to the expense's history, attributed to actorID, in the same transaction.
 type ExpenseRepository interface {
-	CreateExpense(ctx context.Context, expense *model.Expense) error
+	CreateExpense(ctx context.Context, expense *model.Expense, actorID uint) error
+	GetExpenseByID(ctx context.Context, id uint) (*model.Expense, error)
+	UpdateExpense(ctx context.Context, expense *model.Expense, actorID uint) error
+	// DeleteExpense deletes an expense with everything that hangs off it, and returns the
+	// attachments that went with it so that the caller can remove their files.
+	DeleteExpense(ctx context.Context, id uint, actorID uint) ([]model.Attachment, error)
+	// GetExpenseVersions returns an expense's history, oldest version first. It is still
+	// available after the expense has been deleted.
+	GetExpenseVersions(ctx context.Context, expenseID uint) ([]model.ExpenseVersion, error)
 	GetExpensesByGroupID(ctx context.Context, groupID uint) ([]model.Expense, error)
 	GetExpenseSplitsByGroupID(ctx context.Context, groupID uint) ([]model.ExpenseSplit, error)
+	GetExpensePayersByGroupID(ctx context.Context, groupID uint) ([]model.ExpensePayer, error)
+	ListExpenses(ctx context.Context, query ExpenseQuery) (*ExpensePage, error)
+	// StreamExportRows calls fn for every split of the group's expenses, oldest expense first,
+	// reading them from the database one row at a time.
+	StreamExportRows(ctx context.Context, groupID uint, fn func(ExportRow) error) error
+}
+
+// ExportRow is one expense split joined with its expense and the names of the people involved.
+type ExportRow struct {
+	ExpenseID       uint
+	CreatedAt       time.Time
+	Description     string
+	Category        string
+	PayerID         uint
+	PayerName       string // Every payer's name, separated by "; ", when several people paid
+	ParticipantID   uint
+	ParticipantName string
+	Amount          int64 // The expense total, in the expense's currency
+	Share           int64 // The participant's split, in the expense's currency
+	Currency        string
 }
 
 type expenseRepository struct {
@@ -20,9 +58,188 @@ func NewExpenseRepository(db *DB) ExpenseRepository {
 	return &expenseRepository{db: db}
 }
 
-func (r *expenseRepository) CreateExpense(ctx context.Context, expense *model.Expense) error {
-	// GORM's Create with associated slices (like Splits) will insert all within a transaction
-	return r.db.WithContext(ctx).Create(expense).Error
+func (r *expenseRepository) CreateExpense(ctx context.Context, expense *model.Expense, actorID uint) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		// GORM's Create with associated slices (like Splits) inserts them in the same transaction
+		if err := tx.Create(expense).Error; err != nil {
+			return err
+		}
+		return recordExpenseVersion(tx, expense, model.ExpenseCreated, actorID)
+	})
+}
+
+func (r *expenseRepository) GetExpenseByID(ctx context.Context, id uint) (*model.Expense, error) {
+	var expense model.Expense
+	if err := r.db.WithContext(ctx).Preload("Payers").Preload("Splits").Preload("Items.Assignees").First(&expense, id).Error; err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// UpdateExpense overwrites the expense row and replaces all of its payers, splits and line
+// items atomically, so balances never observe a half-updated expense.
+func (r *expenseRepository) UpdateExpense(ctx context.Context, expense *model.Expense, actorID uint) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(expense).Select("payer_id", "amount", "description", "category", "split_type", "currency", "exchange_rate", "tax", "tip").Updates(expense).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expense_id = ?", expense.ID).Delete(&model.ExpensePayer{}).Error; err != nil {
+			return err
+		}
+		for i := range expense.Payers {
+			expense.Payers[i].ID = 0
+			expense.Payers[i].ExpenseID = expense.ID
+		}
+		if len(expense.Payers) > 0 {
+			if err := tx.Create(&expense.Payers).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("expense_id = ?", expense.ID).Delete(&model.ExpenseSplit{}).Error; err != nil {
+			return err
+		}
+		for i := range expense.Splits {
+			expense.Splits[i].ID = 0
+			expense.Splits[i].ExpenseID = expense.ID
+		}
+		if len(expense.Splits) > 0 {
+			if err := tx.Create(&expense.Splits).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := deleteExpenseItems(tx, expense.ID); err != nil {
+			return err
+		}
+		for i := range expense.Items {
+			expense.Items[i].ID = 0
+			expense.Items[i].ExpenseID = expense.ID
+		}
+		if len(expense.Items) > 0 {
+			if err := tx.Create(&expense.Items).Error; err != nil {
+				return err
+			}
+		}
+		return recordExpenseVersion(tx, expense, model.ExpenseUpdated, actorID)
+	})
+}
+
+// deleteExpenseItems removes an expense's line items along with their assignees.
+func deleteExpenseItems(tx *gorm.DB, expenseID uint) error {
+	items := tx.Model(&model.ExpenseItem{}).Select("id").Where("expense_id = ?", expenseID)
+	if err := tx.Where("expense_item_id IN (?)", items).Delete(&model.ExpenseItemAssignee{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("expense_id = ?", expenseID).Delete(&model.ExpenseItem{}).Error
+}
+
+func (r *expenseRepository) DeleteExpense(ctx context.Context, id uint, actorID uint) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		// Lock the expense first so its final state is recorded exactly as it was deleted
+		var expense model.Expense
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Payers").Preload("Splits").Preload("Items.Assignees").
+			First(&expense, id).Error
+		if err != nil {
+			return err
+		}
+		if err := recordExpenseVersion(tx, &expense, model.ExpenseDeleted, actorID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("expense_id = ?", id).Delete(&model.ExpensePayer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expense_id = ?", id).Delete(&model.ExpenseSplit{}).Error; err != nil {
+			return err
+		}
+		// The lock keeps new attachments out, so this is every file the expense leaves behind
+		if err := tx.Where("expense_id = ?", id).Find(&attachments).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expense_id = ?", id).Delete(&model.Attachment{}).Error; err != nil {
+			return err
+		}
+		if err := deleteExpenseItems(tx, id); err != nil {
+			return err
+		}
+		return tx.Delete(&model.Expense{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *expenseRepository) GetExpenseVersions(ctx context.Context, expenseID uint) ([]model.ExpenseVersion, error) {
+	var versions []model.ExpenseVersion
+	err := r.db.WithContext(ctx).Where("expense_id = ?", expenseID).Order("version").Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// recordExpenseVersion appends the expense's current state to its history. Callers have
+// already written to (and so locked) the expense row, which serialises concurrent writers and
+// keeps version numbers gapless.
+func recordExpenseVersion(tx *gorm.DB, expense *model.Expense, action model.ExpenseAction, actorID uint) error {
+	var last int
+	err := tx.Model(&model.ExpenseVersion{}).
+		Where("expense_id = ?", expense.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+
+	version := &model.ExpenseVersion{
+		ExpenseID: expense.ID,
+		GroupID:   expense.GroupID,
+		Version:   last + 1,
+		Action:    action,
+		ActorID:   &actorID,
+		Snapshot:  snapshotExpense(expense),
+	}
+	return tx.Create(version).Error
+}
+
+// snapshotExpense captures the fields of an expense that make up its history. Payers and
+// splits are ordered by user ID so that equal states produce equal snapshots.
+func snapshotExpense(expense *model.Expense) model.ExpenseSnapshot {
+	snapshot := model.ExpenseSnapshot{
+		PayerID:      expense.PayerID,
+		Payers:       []model.SnapshotShare{},
+		Amount:       expense.Amount,
+		Description:  expense.Description,
+		Category:     expense.Category,
+		SplitType:    expense.SplitType,
+		Currency:     expense.Currency,
+		ExchangeRate: expense.ExchangeRate,
+		Tax:          expense.Tax,
+		Tip:          expense.Tip,
+		Splits:       []model.SnapshotShare{},
+	}
+	for _, p := range expense.Payers {
+		snapshot.Payers = append(snapshot.Payers, model.SnapshotShare{UserID: p.UserID, Amount: p.Amount})
+	}
+	for _, split := range expense.Splits {
+		snapshot.Splits = append(snapshot.Splits, model.SnapshotShare{UserID: split.UserID, Amount: split.Amount})
+	}
+	sort.Slice(snapshot.Payers, func(i, j int) bool { return snapshot.Payers[i].UserID < snapshot.Payers[j].UserID })
+	sort.Slice(snapshot.Splits, func(i, j int) bool { return snapshot.Splits[i].UserID < snapshot.Splits[j].UserID })
+
+	for _, item := range expense.Items {
+		entry := model.SnapshotItem{Name: item.Name, UnitAmount: item.UnitAmount, Quantity: item.Quantity, UserIDs: []uint{}}
+		for _, a := range item.Assignees {
+			entry.UserIDs = append(entry.UserIDs, a.UserID)
+		}
+		sort.Slice(entry.UserIDs, func(i, j int) bool { return entry.UserIDs[i] < entry.UserIDs[j] })
+		snapshot.Items = append(snapshot.Items, entry)
+	}
+	return snapshot
 }
 
 func (r *expenseRepository) GetExpensesByGroupID(ctx context.Context, groupID uint) ([]model.Expense, error) {
@@ -45,3 +262,167 @@ func (r *expenseRepository) GetExpenseSplitsByGroupID(ctx context.Context, group
 	}
 	return splits, nil
 }
+
+func (r *expenseRepository) GetExpensePayersByGroupID(ctx context.Context, groupID uint) ([]model.ExpensePayer, error) {
+	var payers []model.ExpensePayer
+	err := r.db.WithContext(ctx).
+		Joins("JOIN expenses ON expenses.id = expense_payers.expense_id").
+		Where("expenses.group_id = ?", groupID).
+		Find(&payers).Error
+	if err != nil {
+		return nil, err
+	}
+	return payers, nil
+}
+
+// ListExpenses returns one page of a group's expenses using keyset pagination on the sort key
+// and ID, so pages stay stable while new expenses are being added.
+func (r *expenseRepository) ListExpenses(ctx context.Context, query ExpenseQuery) (*ExpensePage, error) {
+	query.Normalize()
+	cursor, err := decodeCursor(query.Cursor, query.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	db := r.db.WithContext(ctx).Preload("Payers").Preload("Splits").Preload("Items.Assignees").Where("expenses.group_id = ?", query.GroupID)
+
+	if query.PayerID != 0 {
+		db = db.Where("EXISTS (SELECT 1 FROM expense_payers WHERE expense_payers.expense_id = expenses.id AND expense_payers.user_id = ?)", query.PayerID)
+	}
+	if query.ParticipantID != 0 {
+		db = db.Where("EXISTS (SELECT 1 FROM expense_splits WHERE expense_splits.expense_id = expenses.id AND expense_splits.user_id = ?)", query.ParticipantID)
+	}
+	if query.From != nil {
+		db = db.Where("expenses.created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("expenses.created_at < ?", *query.To)
+	}
+	baseAmount := baseAmountSQL(query.BaseCurrency)
+	if query.MinAmount != nil {
+		db = db.Where(baseAmount+" >= ?", *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		db = db.Where(baseAmount+" <= ?", *query.MaxAmount)
+	}
+	if query.Search != "" {
+		db = db.Where("expenses.description ILIKE ? ESCAPE '\\'", "%"+escapeLike(query.Search)+"%")
+	}
+
+	var column, direction, comparison string
+	switch query.Sort {
+	case SortDateAsc:
+		column, direction, comparison = "expenses.created_at", "ASC", ">"
+	case SortAmountDesc:
+		column, direction, comparison = baseAmount, "DESC", "<"
+	case SortAmountAsc:
+		column, direction, comparison = baseAmount, "ASC", ">"
+	default:
+		column, direction, comparison = "expenses.created_at", "DESC", "<"
+	}
+
+	if cursor != nil {
+		var value interface{} = cursor.CreatedAt
+		if column == baseAmount {
+			value = cursor.Amount
+		}
+		db = db.Where("("+column+", expenses.id) "+comparison+" (?, ?)", value, cursor.ID)
+	}
+
+	var expenses []model.Expense
+	err = db.Order(column + " " + direction).
+		Order("expenses.id " + direction).
+		Limit(query.Limit + 1).
+		Find(&expenses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := newExpensePage(expenses, query.Sort, query.Limit)
+	if page.NextCursor != "" && column == baseAmount {
+		// The cursor needs the sort key exactly as the database computes it
+		last := page.Expenses[len(page.Expenses)-1]
+		var amount int64
+		err := r.db.WithContext(ctx).Model(&model.Expense{}).
+			Where("id = ?", last.ID).
+			Select(baseAmount).
+			Scan(&amount).Error
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = encodeCursor(expenseCursor{Sort: query.Sort, Amount: amount, ID: last.ID})
+	}
+	return page, nil
+}
+
+// baseAmountSQL converts an expense's amount into minor units of the base currency at the rate
+// captured when it was added, so that amounts in different currencies compare by their worth.
+func baseAmountSQL(baseCurrency string) string {
+	digits := currency.MinorUnits(currency.Normalize(baseCurrency))
+	return fmt.Sprintf("ROUND(expenses.amount * expenses.exchange_rate::numeric * CASE "+
+		"WHEN expenses.currency IN (%s) THEN power(10::numeric, %d) "+
+		"WHEN expenses.currency IN (%s) THEN power(10::numeric, %d) "+
+		"ELSE power(10::numeric, %d) END)::bigint",
+		quoteCodes(currency.CodesWithMinorUnits(0)), digits,
+		quoteCodes(currency.CodesWithMinorUnits(3)), digits-3,
+		digits-2)
+}
+
+// quoteCodes renders currency codes as a list of SQL string literals.
+func quoteCodes(codes []string) string {
+	quoted := make([]string, len(codes))
+	for i, code := range codes {
+		quoted[i] = "'" + code + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// StreamExportRows scans the rows straight off the query, and stops at the first error fn returns.
+func (r *expenseRepository) StreamExportRows(ctx context.Context, groupID uint, fn func(ExportRow) error) error {
+	rows, err := r.db.WithContext(ctx).
+		Table("expense_splits AS s").
+		Select("e.id AS expense_id, e.created_at, e.description, e.category, e.payer_id, "+
+			"COALESCE((SELECT string_agg(u.name, '; ' ORDER BY p.amount DESC, u.name) FROM expense_payers AS p "+
+			"JOIN users AS u ON u.id = p.user_id WHERE p.expense_id = e.id), payer.name) AS payer_name, "+
+			"s.user_id AS participant_id, participant.name AS participant_name, e.amount, s.amount AS share, e.currency").
+		Joins("JOIN expenses AS e ON e.id = s.expense_id").
+		Joins("JOIN users AS payer ON payer.id = e.payer_id").
+		Joins("JOIN users AS participant ON participant.id = s.user_id").
+		Where("e.group_id = ?", groupID).
+		Order("e.created_at, e.id, s.user_id").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ExportRow
+		if err := r.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// newExpensePage trims the one extra row fetched to detect whether another page exists.
+func newExpensePage(expenses []model.Expense, sort ExpenseSort, limit int) *ExpensePage {
+	page := &ExpensePage{Expenses: expenses}
+	if len(expenses) > limit {
+		page.Expenses = expenses[:limit]
+		last := page.Expenses[limit-1]
+		page.NextCursor = encodeCursor(expenseCursor{Sort: sort, CreatedAt: last.CreatedAt, Amount: last.Amount, ID: last.ID})
+	}
+	if page.Expenses == nil {
+		page.Expenses = []model.Expense{}
+	}
+	return page
+}
+
+// escapeLike escapes the LIKE wildcards in user input so they match literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
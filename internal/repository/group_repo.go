@@ -3,13 +3,23 @@ package repository
 import (
 	"context"
 
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
 	"expense-tracker/internal/model"
 )
 
 type GroupRepository interface {
-	CreateGroup(ctx context.Context, group *model.Group) error
+	CreateGroup(ctx context.Context, group *model.Group, memberIDs []uint) error
 	GetGroupByID(ctx context.Context, id uint) (*model.Group, error)
 	AddUsersToGroup(ctx context.Context, groupID uint, userIDs []uint) error
+	IsMember(ctx context.Context, groupID uint, userID uint) (bool, error)
+	GetMemberIDs(ctx context.Context, groupID uint) ([]uint, error)
+	GetMembers(ctx context.Context, groupID uint) ([]model.User, error)
+	RemoveUserFromGroup(ctx context.Context, groupID uint, userID uint) error
+	GetGroupsByUserID(ctx context.Context, userID uint) ([]model.Group, error)
+	UpdateGroupTitle(ctx context.Context, groupID uint, title string) error
+	LockGroup(ctx context.Context, groupID uint) error
 }
 
 type groupRepository struct {
@@ -20,8 +30,17 @@ func NewGroupRepository(db *DB) GroupRepository {
 	return &groupRepository{db: db}
 }
 
-func (r *groupRepository) CreateGroup(ctx context.Context, group *model.Group) error {
-	return r.db.WithContext(ctx).Create(group).Error
+// CreateGroup inserts the group together with its initial members in one transaction.
+func (r *groupRepository) CreateGroup(ctx context.Context, group *model.Group, memberIDs []uint) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		if len(memberIDs) == 0 {
+			return nil
+		}
+		return tx.Create(newGroupMembers(group.ID, memberIDs)).Error
+	})
 }
 
 func (r *groupRepository) GetGroupByID(ctx context.Context, id uint) (*model.Group, error) {
@@ -32,7 +51,84 @@ func (r *groupRepository) GetGroupByID(ctx context.Context, id uint) (*model.Gro
 	return &group, nil
 }
 
+// AddUsersToGroup adds the users as members, skipping any that already belong to the group.
 func (r *groupRepository) AddUsersToGroup(ctx context.Context, groupID uint, userIDs []uint) error {
+	members := newGroupMembers(groupID, userIDs)
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+}
+
+func (r *groupRepository) IsMember(ctx context.Context, groupID uint, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.GroupMember{}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *groupRepository) GetMemberIDs(ctx context.Context, groupID uint) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.WithContext(ctx).Model(&model.GroupMember{}).
+		Where("group_id = ?", groupID).
+		Order("user_id").
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+func (r *groupRepository) GetMembers(ctx context.Context, groupID uint) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).
+		Joins("JOIN group_members ON group_members.user_id = users.id").
+		Where("group_members.group_id = ?", groupID).
+		Order("users.id").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *groupRepository) RemoveUserFromGroup(ctx context.Context, groupID uint, userID uint) error {
+	return r.db.WithContext(ctx).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&model.GroupMember{}).Error
+}
+
+func (r *groupRepository) GetGroupsByUserID(ctx context.Context, userID uint) ([]model.Group, error) {
+	var groups []model.Group
+	err := r.db.WithContext(ctx).
+		Joins("JOIN group_members ON group_members.group_id = groups.id").
+		Where("group_members.user_id = ?", userID).
+		Order("groups.id").
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *groupRepository) UpdateGroupTitle(ctx context.Context, groupID uint, title string) error {
+	return r.db.WithContext(ctx).Model(&model.Group{}).
+		Where("id = ?", groupID).
+		Update("title", title).Error
+}
+
+// LockGroup locks the group's row until the surrounding transaction ends. Inserting an
+// expense or payment into the group checks its foreign key against that row, so the lock
+// waits for writes already in flight and holds back new ones.
+func (r *groupRepository) LockGroup(ctx context.Context, groupID uint) error {
+	var group model.Group
+	return r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&group, groupID).Error
+}
+
+func newGroupMembers(groupID uint, userIDs []uint) []model.GroupMember {
 	var members []model.GroupMember
 	for _, uid := range userIDs {
 		members = append(members, model.GroupMember{
@@ -40,5 +136,5 @@ func (r *groupRepository) AddUsersToGroup(ctx context.Context, groupID uint, use
 			UserID:  uid,
 		})
 	}
-	return r.db.WithContext(ctx).Create(&members).Error
+	return members
 }
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"expense-tracker/internal/model"
+)
+
+// NotificationRepository stores email preferences and which weekly digests were sent.
+type NotificationRepository interface {
+	// GetPreferences returns the users' preferences by user ID, with the defaults for users
+	// who never changed theirs.
+	GetPreferences(ctx context.Context, userIDs []uint) (map[uint]model.NotificationPreferences, error)
+	SavePreferences(ctx context.Context, prefs *model.NotificationPreferences) error
+	// GetDigestRecipients returns the users with an email address who belong to a group,
+	// haven't turned the weekly digest off and haven't had the digest of the given week yet.
+	GetDigestRecipients(ctx context.Context, weekStart time.Time) ([]model.User, error)
+	// ClaimDigest reserves the user's digest for the week. It reports false when it was
+	// already claimed, e.g. by a previous run or another server instance.
+	ClaimDigest(ctx context.Context, userID uint, weekStart time.Time) (bool, error)
+	// ReleaseDigest drops a claim whose digest could not be sent so it's retried later.
+	ReleaseDigest(ctx context.Context, userID uint, weekStart time.Time) error
+}
+
+type notificationRepository struct {
+	db *DB
+}
+
+func NewNotificationRepository(db *DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) GetPreferences(ctx context.Context, userIDs []uint) (map[uint]model.NotificationPreferences, error) {
+	var rows []model.NotificationPreferences
+	if err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	prefs := make(map[uint]model.NotificationPreferences, len(userIDs))
+	for _, id := range userIDs {
+		prefs[id] = model.NotificationPreferences{UserID: id, ExpenseAdded: true, PaymentReceived: true, WeeklyDigest: true}
+	}
+	for _, p := range rows {
+		prefs[p.UserID] = p
+	}
+	return prefs, nil
+}
+
+func (r *notificationRepository) SavePreferences(ctx context.Context, prefs *model.NotificationPreferences) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expense_added", "payment_received", "weekly_digest", "updated_at"}),
+	}).Create(prefs).Error
+}
+
+func (r *notificationRepository) GetDigestRecipients(ctx context.Context, weekStart time.Time) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).
+		Where("email <> ''").
+		Where("EXISTS (SELECT 1 FROM group_members WHERE group_members.user_id = users.id)").
+		Where("NOT EXISTS (SELECT 1 FROM notification_preferences p WHERE p.user_id = users.id AND NOT p.weekly_digest)").
+		Where("NOT EXISTS (SELECT 1 FROM weekly_digests d WHERE d.user_id = users.id AND d.week_start = ?)", weekStart).
+		Order("id").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *notificationRepository) ClaimDigest(ctx context.Context, userID uint, weekStart time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.WeeklyDigest{
+		UserID:    userID,
+		WeekStart: weekStart,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *notificationRepository) ReleaseDigest(ctx context.Context, userID uint, weekStart time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND week_start = ?", userID, weekStart).
+		Delete(&model.WeeklyDigest{}).Error
+}
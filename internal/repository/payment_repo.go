@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"expense-tracker/internal/model"
+)
+
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, payment *model.Payment) error
+	GetPaymentsByGroupID(ctx context.Context, groupID uint) ([]model.Payment, error)
+}
+
+type paymentRepository struct {
+	db *DB
+}
+
+func NewPaymentRepository(db *DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) CreatePayment(ctx context.Context, payment *model.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepository) GetPaymentsByGroupID(ctx context.Context, groupID uint) ([]model.Payment, error) {
+	var payments []model.Payment
+	if err := r.db.WithContext(ctx).Where("group_id = ?", groupID).Order("created_at").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"expense-tracker/internal/model"
+)
+
+type RecurringExpenseRepository interface {
+	CreateRecurringExpense(ctx context.Context, recurring *model.RecurringExpense) error
+	GetRecurringExpenseByID(ctx context.Context, id uint) (*model.RecurringExpense, error)
+	GetRecurringExpensesByGroupID(ctx context.Context, groupID uint) ([]model.RecurringExpense, error)
+	DeleteRecurringExpense(ctx context.Context, id uint) error
+	GetDueRecurringExpenses(ctx context.Context, now time.Time) ([]model.RecurringExpense, error)
+	ClaimOccurrence(ctx context.Context, recurringID uint, occurrence time.Time) (bool, error)
+	CompleteOccurrence(ctx context.Context, recurringID uint, occurrence time.Time, expenseID uint, nextRunAt time.Time, active bool) error
+	AdvanceRecurringExpense(ctx context.Context, recurringID uint, nextRunAt time.Time, active bool) error
+}
+
+type recurringExpenseRepository struct {
+	db *DB
+}
+
+func NewRecurringExpenseRepository(db *DB) RecurringExpenseRepository {
+	return &recurringExpenseRepository{db: db}
+}
+
+func (r *recurringExpenseRepository) CreateRecurringExpense(ctx context.Context, recurring *model.RecurringExpense) error {
+	return r.db.WithContext(ctx).Create(recurring).Error
+}
+
+func (r *recurringExpenseRepository) GetRecurringExpenseByID(ctx context.Context, id uint) (*model.RecurringExpense, error) {
+	var recurring model.RecurringExpense
+	if err := r.db.WithContext(ctx).Preload("Splits").First(&recurring, id).Error; err != nil {
+		return nil, err
+	}
+	return &recurring, nil
+}
+
+func (r *recurringExpenseRepository) GetRecurringExpensesByGroupID(ctx context.Context, groupID uint) ([]model.RecurringExpense, error) {
+	var recurring []model.RecurringExpense
+	err := r.db.WithContext(ctx).Preload("Splits").
+		Where("group_id = ?", groupID).
+		Order("id").
+		Find(&recurring).Error
+	if err != nil {
+		return nil, err
+	}
+	return recurring, nil
+}
+
+func (r *recurringExpenseRepository) DeleteRecurringExpense(ctx context.Context, id uint) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Where("recurring_expense_id = ?", id).Delete(&model.RecurringExpenseSplit{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("recurring_expense_id = ?", id).Delete(&model.RecurringExpenseOccurrence{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.RecurringExpense{}, id).Error
+	})
+}
+
+func (r *recurringExpenseRepository) GetDueRecurringExpenses(ctx context.Context, now time.Time) ([]model.RecurringExpense, error) {
+	var recurring []model.RecurringExpense
+	err := r.db.WithContext(ctx).Preload("Splits").
+		Where("active AND next_run_at <= ?", now).
+		Order("next_run_at").
+		Find(&recurring).Error
+	if err != nil {
+		return nil, err
+	}
+	return recurring, nil
+}
+
+// ClaimOccurrence reserves an occurrence for materialisation. It reports false when the
+// occurrence was already claimed, e.g. by a previous run or another server instance. Called
+// in a transaction, the claim holds only if the transaction commits.
+func (r *recurringExpenseRepository) ClaimOccurrence(ctx context.Context, recurringID uint, occurrence time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.RecurringExpenseOccurrence{
+		RecurringExpenseID: recurringID,
+		OccurrenceDate:     occurrence,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CompleteOccurrence links the claimed occurrence to its expense and moves the template forward.
+func (r *recurringExpenseRepository) CompleteOccurrence(ctx context.Context, recurringID uint, occurrence time.Time, expenseID uint, nextRunAt time.Time, active bool) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		err := tx.Model(&model.RecurringExpenseOccurrence{}).
+			Where("recurring_expense_id = ? AND occurrence_date = ?", recurringID, occurrence).
+			Update("expense_id", expenseID).Error
+		if err != nil {
+			return err
+		}
+		return advanceRecurring(tx, recurringID, nextRunAt, active)
+	})
+}
+
+func (r *recurringExpenseRepository) AdvanceRecurringExpense(ctx context.Context, recurringID uint, nextRunAt time.Time, active bool) error {
+	return advanceRecurring(r.db.WithContext(ctx), recurringID, nextRunAt, active)
+}
+
+func advanceRecurring(tx *gorm.DB, recurringID uint, nextRunAt time.Time, active bool) error {
+	return tx.Model(&model.RecurringExpense{}).
+		Where("id = ?", recurringID).
+		Updates(map[string]interface{}{"next_run_at": nextRunAt, "active": active}).Error
+}
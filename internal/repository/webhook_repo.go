@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+
+	"expense-tracker/internal/model"
+)
+
+// WebhookRepository stores webhook subscriptions and their delivery queue.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	GetWebhookByID(ctx context.Context, id uint) (*model.Webhook, error)
+	GetWebhooksByGroupID(ctx context.Context, groupID uint) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uint) error
+
+	CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	// GetDeliveries returns a webhook's most recent deliveries, newest first.
+	GetDeliveries(ctx context.Context, webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	// ClaimDueDeliveries takes up to limit pending deliveries whose next attempt is due and
+	// pushes their next attempt back by lease, so that other workers skip them while they are
+	// being sent. A worker that dies mid-send only delays the delivery by the lease.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error)
+	// UpdateDelivery saves the outcome of an attempt.
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+}
+
+// claimDeliveriesSQL leases due deliveries. SKIP LOCKED lets several server instances drain
+// the queue at once without sending anything twice.
+const claimDeliveriesSQL = `
+UPDATE webhook_deliveries SET next_attempt_at = @leased, updated_at = @now
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = @pending AND next_attempt_at <= @now
+    ORDER BY next_attempt_at, id
+    LIMIT @limit
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+type webhookRepository struct {
+	db *DB
+}
+
+func NewWebhookRepository(db *DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *webhookRepository) GetWebhookByID(ctx context.Context, id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := r.db.WithContext(ctx).First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) GetWebhooksByGroupID(ctx context.Context, groupID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := r.db.WithContext(ctx).Where("group_id = ?", groupID).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) DeleteWebhook(ctx context.Context, id uint) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Webhook{}, id).Error
+	})
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (r *webhookRepository) GetDeliveries(ctx context.Context, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.WithContext(ctx).Raw(claimDeliveriesSQL,
+		sql.Named("now", now),
+		sql.Named("leased", now.Add(lease)),
+		sql.Named("pending", model.DeliveryPending),
+		sql.Named("limit", limit),
+	).Scan(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_status", "last_error", "delivered_at").
+		Updates(delivery).Error
+}
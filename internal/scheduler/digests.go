@@ -0,0 +1,16 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"expense-tracker/internal/service"
+)
+
+// NewDigestWorker periodically sends the weekly digest emails once they are due.
+func NewDigestWorker(svc service.NotificationService, interval time.Duration, logger *slog.Logger) *Worker {
+	return newWorker(func(ctx context.Context) (int, error) {
+		return svc.SendWeeklyDigests(ctx, time.Now())
+	}, interval, logger, "sent weekly digests", "failed to send weekly digests")
+}
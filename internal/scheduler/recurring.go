@@ -0,0 +1,16 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"expense-tracker/internal/service"
+)
+
+// NewRecurringWorker periodically materialises due recurring expenses in the background.
+func NewRecurringWorker(svc service.RecurringExpenseService, interval time.Duration, logger *slog.Logger) *Worker {
+	return newWorker(func(ctx context.Context) (int, error) {
+		return svc.MaterializeDue(ctx, time.Now())
+	}, interval, logger, "materialised recurring expenses", "failed to materialise recurring expenses")
+}
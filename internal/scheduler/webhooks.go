@@ -0,0 +1,17 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"expense-tracker/internal/service"
+)
+
+// NewWebhookWorker periodically sends queued webhook deliveries whose next attempt is due.
+// Deliveries cut off by Stop are retried once their lease runs out.
+func NewWebhookWorker(svc service.WebhookService, interval time.Duration, logger *slog.Logger) *Worker {
+	return newWorker(func(ctx context.Context) (int, error) {
+		return svc.DeliverDue(ctx, time.Now())
+	}, interval, logger, "delivered webhooks", "failed to deliver webhooks")
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Worker runs a periodic job in the background. The job reports how many items it handled.
+type Worker struct {
+	run      func(ctx context.Context) (int, error)
+	interval time.Duration
+	logger   *slog.Logger
+	done     string // Logged with the count after a run that handled anything
+	failed   string // Logged with the error after a run that failed
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+func newWorker(run func(ctx context.Context) (int, error), interval time.Duration, logger *slog.Logger, done, failed string) *Worker {
+	return &Worker{run: run, interval: interval, logger: logger, done: done, failed: failed}
+}
+
+// Start launches the worker goroutine. It runs once immediately and then on every tick.
+func (w *Worker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.stopped = make(chan struct{})
+
+	go func() {
+		defer close(w.stopped)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			w.runOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels any in-flight run and blocks until the worker goroutine has exited.
+func (w *Worker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.stopped
+}
+
+func (w *Worker) runOnce(ctx context.Context) {
+	count, err := w.run(ctx)
+	if count > 0 {
+		w.logger.Info(w.done, slog.Int("count", count))
+	}
+	if err != nil && ctx.Err() == nil {
+		w.logger.Error(w.failed, slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+)
+
+var (
+	ErrInvalidActivity = errors.New("activity does not belong to this group")
+)
+
+// ActivityRecorder appends events to the activity feed. Services that change a group record
+// an activity in the same transaction as each write, attributed to the authenticated caller.
+type ActivityRecorder interface {
+	Record(ctx context.Context, activity *model.Activity) error
+}
+
+// ActivityQueue persists follow-up work for an activity, such as webhook deliveries, in the
+// transaction that records it, so the work is committed or rolled back together with the write.
+// An error fails the write.
+type ActivityQueue interface {
+	Enqueue(ctx context.Context, activity *model.Activity) error
+}
+
+// ActivityListener is told about every activity once the write that caused it has committed,
+// for example to push it to live streams. The write has already happened, so an error is only
+// logged.
+type ActivityListener interface {
+	OnActivity(ctx context.Context, activity *model.Activity) error
+}
+
+type ActivityService interface {
+	ActivityRecorder
+	// GetGroupFeed returns one page of the group's activities, newest first.
+	GetGroupFeed(ctx context.Context, groupID uint, cursor string, limit int) (*model.ActivityFeed, error)
+	// GetUserFeed returns one page of the activities of every group the user belongs to.
+	GetUserFeed(ctx context.Context, userID uint, cursor string, limit int) (*model.ActivityFeed, error)
+	// MarkRead marks the group's activities up to activityID as read by the caller; zero marks
+	// everything read.
+	MarkRead(ctx context.Context, groupID uint, activityID uint) error
+}
+
+type quietActivitiesKey struct{}
+
+// WithQuietActivities returns a copy of ctx in which activities are still recorded in the feed
+// but neither queued nor passed to listeners. Bulk writes such as imports use it so that a
+// thousand rows don't turn into a thousand emails and webhook deliveries.
+func WithQuietActivities(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietActivitiesKey{}, true)
+}
+
+func quietActivities(ctx context.Context) bool {
+	quiet, _ := ctx.Value(quietActivitiesKey{}).(bool)
+	return quiet
+}
+
+type activityService struct {
+	repo      repository.ActivityRepository
+	groupRepo repository.GroupRepository
+	queue     ActivityQueue
+	logger    *slog.Logger
+	listeners []ActivityListener
+}
+
+// NewActivityService builds the activity feed. queue may be nil when nothing needs queueing.
+func NewActivityService(repo repository.ActivityRepository, groupRepo repository.GroupRepository, queue ActivityQueue, logger *slog.Logger, listeners ...ActivityListener) ActivityService {
+	return &activityService{repo: repo, groupRepo: groupRepo, queue: queue, logger: logger, listeners: listeners}
+}
+
+// Record fills in the actor from the context unless one was set, so background work such as
+// recurring expenses is attributed to the user it runs on behalf of. Under WithQuietActivities
+// it only writes the feed.
+func (s *activityService) Record(ctx context.Context, activity *model.Activity) error {
+	if activity.ActorID == 0 {
+		actorID, ok := auth.UserIDFromContext(ctx)
+		if !ok {
+			return ErrUnauthenticated
+		}
+		activity.ActorID = actorID
+	}
+	if err := s.repo.CreateActivity(ctx, activity); err != nil {
+		return err
+	}
+	if quietActivities(ctx) {
+		return nil
+	}
+	if s.queue != nil {
+		if err := s.queue.Enqueue(ctx, activity); err != nil {
+			return err
+		}
+	}
+
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		for _, l := range s.listeners {
+			if err := l.OnActivity(ctx, activity); err != nil {
+				s.logger.Error("activity listener failed", slog.Uint64("activity_id", uint64(activity.ID)), slog.String("type", string(activity.Type)), slog.String("error", err.Error()))
+			}
+		}
+	})
+	return nil
+}
+
+func (s *activityService) GetGroupFeed(ctx context.Context, groupID uint, cursor string, limit int) (*model.ActivityFeed, error) {
+	userID, err := requireMember(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.feed(ctx, userID, []uint{groupID}, cursor, limit)
+}
+
+func (s *activityService) GetUserFeed(ctx context.Context, userID uint, cursor string, limit int) (*model.ActivityFeed, error) {
+	callerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if callerID != userID {
+		return nil, ErrForbidden
+	}
+
+	groups, err := s.groupRepo.GetGroupsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs := make([]uint, len(groups))
+	for i, g := range groups {
+		groupIDs[i] = g.ID
+	}
+	return s.feed(ctx, userID, groupIDs, cursor, limit)
+}
+
+func (s *activityService) MarkRead(ctx context.Context, groupID uint, activityID uint) error {
+	userID, err := requireMember(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return err
+	}
+
+	latest, err := s.repo.GetLatestActivityID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if activityID == 0 {
+		activityID = latest
+	}
+	// Marking past the newest activity would hide activities that haven't happened yet
+	if activityID > latest {
+		return ErrInvalidActivity
+	}
+	if activityID == 0 {
+		return nil
+	}
+	return s.repo.MarkRead(ctx, userID, groupID, activityID)
+}
+
+// feed loads one page of the groups' activities and flags the ones the reader hasn't seen.
+func (s *activityService) feed(ctx context.Context, userID uint, groupIDs []uint, cursor string, limit int) (*model.ActivityFeed, error) {
+	activities, next, err := s.repo.ListActivities(ctx, repository.ActivityQuery{GroupIDs: groupIDs, Cursor: cursor, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	markers, err := s.repo.GetReadMarkers(ctx, userID, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range activities {
+		a := &activities[i]
+		a.Unread = a.ActorID != userID && a.ID > markers[a.GroupID]
+	}
+
+	unread, err := s.repo.CountUnread(ctx, userID, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ActivityFeed{Activities: activities, NextCursor: next, UnreadCount: unread}, nil
+}
+
+// expenseActivity describes an expense event from the expense's state at the time.
+func expenseActivity(activityType model.ActivityType, expense *model.Expense) *model.Activity {
+	return &model.Activity{
+		GroupID:   expense.GroupID,
+		Type:      activityType,
+		ExpenseID: &expense.ID,
+		Data: model.ActivityData{
+			Description: expense.Description,
+			Amount:      expense.Amount,
+			Currency:    expense.Currency,
+		},
+	}
+}
+
+// paymentActivity describes a recorded payment; the receiver is the activity's user.
+func paymentActivity(payment *model.Payment, currency string) *model.Activity {
+	return &model.Activity{
+		GroupID:   payment.GroupID,
+		Type:      model.ActivityPaymentRecorded,
+		UserID:    &payment.ToUserID,
+		PaymentID: &payment.ID,
+		Data: model.ActivityData{
+			Description: payment.Note,
+			Amount:      payment.Amount,
+			Currency:    currency,
+		},
+	}
+}
+
+// memberActivity describes a member joining or leaving a group.
+func memberActivity(activityType model.ActivityType, groupID uint, userID uint) *model.Activity {
+	return &model.Activity{GroupID: groupID, Type: activityType, UserID: &userID}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/currency"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+)
+
+var (
+	ErrInvalidInterval = errors.New("interval must be day, week or month")
+	ErrRangeTooLarge   = errors.New("the requested range has too many buckets for this interval")
+)
+
+// MaxSeriesPoints caps the number of buckets in a single series.
+const MaxSeriesPoints = 1000
+
+type AnalyticsService interface {
+	// GetGroupAnalytics returns a spending series for every member of the group. From is rounded
+	// down to the start of its bucket and To is exclusive; either may be nil.
+	GetGroupAnalytics(ctx context.Context, groupID uint, interval model.AnalyticsInterval, from, to *time.Time) (*model.SpendingAnalytics, error)
+	// GetUserAnalytics returns the caller's spending across all of their groups, one series per currency.
+	GetUserAnalytics(ctx context.Context, userID uint, interval model.AnalyticsInterval, from, to *time.Time) (*model.SpendingAnalytics, error)
+}
+
+type analyticsService struct {
+	repo      repository.AnalyticsRepository
+	groupRepo repository.GroupRepository
+}
+
+func NewAnalyticsService(repo repository.AnalyticsRepository, groupRepo repository.GroupRepository) AnalyticsService {
+	return &analyticsService{repo: repo, groupRepo: groupRepo}
+}
+
+func (s *analyticsService) GetGroupAnalytics(ctx context.Context, groupID uint, interval model.AnalyticsInterval, from, to *time.Time) (*model.SpendingAnalytics, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+
+	group, err := getGroup(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &model.SpendingAnalytics{GroupID: groupID, Interval: interval, From: from, To: to}
+	analytics.Series, err = s.spendingSeries(ctx, []model.Group{*group}, 0, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+func (s *analyticsService) GetUserAnalytics(ctx context.Context, userID uint, interval model.AnalyticsInterval, from, to *time.Time) (*model.SpendingAnalytics, error) {
+	callerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if callerID != userID {
+		return nil, ErrForbidden
+	}
+
+	groups, err := s.groupRepo.GetGroupsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &model.SpendingAnalytics{UserID: userID, Interval: interval, From: from, To: to}
+	analytics.Series, err = s.spendingSeries(ctx, groups, userID, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+// spendingSeries loads the pre-aggregated buckets, converts them into each group's base
+// currency and turns them into gap-free series with a running balance. Buckets before from
+// are not returned but still count towards the opening balance.
+func (s *analyticsService) spendingSeries(ctx context.Context, groups []model.Group, userID uint, interval model.AnalyticsInterval, from, to *time.Time) ([]model.SpendingSeries, error) {
+	if interval != model.IntervalDay && interval != model.IntervalWeek && interval != model.IntervalMonth {
+		return nil, ErrInvalidInterval
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, ErrInvalidRange
+	}
+
+	groupIDs := make([]uint, len(groups))
+	baseCurrencies := make(map[uint]string, len(groups))
+	for i, g := range groups {
+		groupIDs[i] = g.ID
+		baseCurrencies[g.ID] = currency.Normalize(g.BaseCurrency)
+	}
+
+	buckets, err := s.repo.GetSpendingBuckets(ctx, repository.SpendingQuery{
+		GroupIDs: groupIDs,
+		UserID:   userID,
+		Interval: string(interval),
+		To:       to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type seriesKey struct {
+		userID   uint
+		currency string
+	}
+	type bucketTotals struct {
+		paid, consumed, net int64
+	}
+	totals := make(map[seriesKey]map[time.Time]*bucketTotals)
+
+	for _, b := range buckets {
+		base := baseCurrencies[b.GroupID]
+		amount := b.Amount
+		if b.Currency != "" && currency.Normalize(b.Currency) != base {
+			amount = currency.Convert(b.Amount, currency.Normalize(b.Currency), base, b.ExchangeRate)
+		}
+
+		key := seriesKey{userID: b.UserID, currency: base}
+		if totals[key] == nil {
+			totals[key] = make(map[time.Time]*bucketTotals)
+		}
+		period := truncatePeriod(b.Period, interval)
+		t, ok := totals[key][period]
+		if !ok {
+			t = &bucketTotals{}
+			totals[key][period] = t
+		}
+
+		switch b.Kind {
+		case repository.BucketPaid:
+			t.paid += amount
+			t.net += amount
+		case repository.BucketConsumed:
+			t.consumed += amount
+			t.net -= amount
+		case repository.BucketSent:
+			t.net += amount
+		case repository.BucketReceived:
+			t.net -= amount
+		}
+	}
+
+	// Every series covers the same range so that they line up in a chart: from the start of the
+	// requested range (or the earliest bucket) up to its end (or the current bucket)
+	var first, last time.Time
+	if from != nil {
+		first = truncatePeriod(*from, interval)
+	}
+	if to != nil {
+		last = truncatePeriod(to.Add(-time.Nanosecond), interval)
+	} else {
+		last = truncatePeriod(time.Now(), interval)
+	}
+	for _, byPeriod := range totals {
+		for p := range byPeriod {
+			if from == nil && (first.IsZero() || p.Before(first)) {
+				first = p
+			}
+			if to == nil && p.After(last) {
+				last = p
+			}
+		}
+	}
+
+	series := []model.SpendingSeries{}
+	for key, byPeriod := range totals {
+		var balance int64
+		for p, t := range byPeriod {
+			if p.Before(first) {
+				balance += t.net
+			}
+		}
+
+		points := []model.SeriesPoint{}
+		for p := first; !p.After(last); p = nextPeriod(p, interval) {
+			if len(points) == MaxSeriesPoints {
+				return nil, ErrRangeTooLarge
+			}
+			point := model.SeriesPoint{Period: p}
+			if t, ok := byPeriod[p]; ok {
+				point.Paid = t.paid
+				point.Consumed = t.consumed
+				balance += t.net
+			}
+			point.Balance = balance
+			points = append(points, point)
+		}
+
+		series = append(series, model.SpendingSeries{UserID: key.userID, Currency: key.currency, Points: points})
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Currency != series[j].Currency {
+			return series[i].Currency < series[j].Currency
+		}
+		return series[i].UserID < series[j].UserID
+	})
+	return series, nil
+}
+
+// truncatePeriod returns the start of the UTC bucket containing t, matching Postgres' date_trunc.
+func truncatePeriod(t time.Time, interval model.AnalyticsInterval) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case model.IntervalWeek:
+		// time.Weekday starts on Sunday, ISO weeks on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case model.IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextPeriod(t time.Time, interval model.AnalyticsInterval) time.Time {
+	switch interval {
+	case model.IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case model.IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/storage"
+)
+
+var (
+	ErrAttachmentNotFound   = errors.New("attachment not found")
+	ErrAttachmentTooLarge   = errors.New("attachment exceeds the maximum size")
+	ErrEmptyAttachment      = errors.New("attachment is empty")
+	ErrUnsupportedMediaType = errors.New("unsupported attachment type, expected a JPEG, PNG, GIF, WebP image or a PDF")
+)
+
+// allowedAttachmentTypes are the MIME types accepted for receipts. The type is sniffed from
+// the file's content; the name and the client's Content-Type are never trusted.
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+type AttachmentService interface {
+	UploadAttachment(ctx context.Context, groupID uint, expenseID uint, fileName string, size int64, content io.Reader) (*model.Attachment, error)
+	GetAttachments(ctx context.Context, groupID uint, expenseID uint) ([]model.Attachment, error)
+	// OpenAttachment returns the attachment and its content; the caller must close the content.
+	OpenAttachment(ctx context.Context, groupID uint, expenseID uint, attachmentID uint) (*model.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, groupID uint, expenseID uint, attachmentID uint) error
+}
+
+type attachmentService struct {
+	repo        repository.AttachmentRepository
+	expenseRepo repository.ExpenseRepository
+	groupRepo   repository.GroupRepository
+	blobs       storage.BlobStore
+	maxSize     int64
+}
+
+func NewAttachmentService(repo repository.AttachmentRepository, expenseRepo repository.ExpenseRepository, groupRepo repository.GroupRepository, blobs storage.BlobStore, maxSize int64) AttachmentService {
+	return &attachmentService{repo: repo, expenseRepo: expenseRepo, groupRepo: groupRepo, blobs: blobs, maxSize: maxSize}
+}
+
+func (s *attachmentService) UploadAttachment(ctx context.Context, groupID uint, expenseID uint, fileName string, size int64, content io.Reader) (*model.Attachment, error) {
+	callerID, err := requireMember(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := getGroupExpense(ctx, s.expenseRepo, groupID, expenseID); err != nil {
+		return nil, err
+	}
+
+	if size <= 0 {
+		return nil, ErrEmptyAttachment
+	}
+	if size > s.maxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	// Sniff the type from the first bytes, then hand the store the whole file again
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !allowedAttachmentTypes[contentType] {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	key, err := newStorageKey(expenseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Put(ctx, key, io.MultiReader(bytes.NewReader(head), content), size, contentType); err != nil {
+		return nil, err
+	}
+
+	attachment := &model.Attachment{
+		ExpenseID:    expenseID,
+		FileName:     cleanFileName(fileName),
+		ContentType:  contentType,
+		Size:         size,
+		StorageKey:   key,
+		UploadedByID: callerID,
+	}
+	if err := s.repo.CreateAttachment(ctx, attachment); err != nil {
+		return nil, errors.Join(err, s.blobs.Delete(ctx, key))
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) GetAttachments(ctx context.Context, groupID uint, expenseID uint) ([]model.Attachment, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+	if _, err := getGroupExpense(ctx, s.expenseRepo, groupID, expenseID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAttachmentsByExpenseID(ctx, expenseID)
+}
+
+func (s *attachmentService) OpenAttachment(ctx context.Context, groupID uint, expenseID uint, attachmentID uint) (*model.Attachment, io.ReadCloser, error) {
+	attachment, err := s.getAttachment(ctx, groupID, expenseID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.blobs.Get(ctx, attachment.StorageKey)
+	if err == storage.ErrNotFound {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
+
+func (s *attachmentService) DeleteAttachment(ctx context.Context, groupID uint, expenseID uint, attachmentID uint) error {
+	attachment, err := s.getAttachment(ctx, groupID, expenseID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteAttachment(ctx, attachment.ID); err != nil {
+		return err
+	}
+	return s.blobs.Delete(ctx, attachment.StorageKey)
+}
+
+// getAttachment loads an attachment after checking that the caller may read the group and
+// that the attachment really belongs to the expense in the URL.
+func (s *attachmentService) getAttachment(ctx context.Context, groupID uint, expenseID uint, attachmentID uint) (*model.Attachment, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+	if _, err := getGroupExpense(ctx, s.expenseRepo, groupID, expenseID); err != nil {
+		return nil, err
+	}
+
+	attachment, err := s.repo.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	if attachment.ExpenseID != expenseID {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+// newStorageKey returns a fresh, unguessable key for an expense's attachment.
+func newStorageKey(expenseID uint) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("expenses/%d/%s", expenseID, hex.EncodeToString(random)), nil
+}
+
+// cleanFileName keeps only the base name of an uploaded file, for display and downloads.
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == '"' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+	return name
+}
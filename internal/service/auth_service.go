@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+)
+
+var (
+	ErrEmailTaken         = errors.New("an account with this email already exists")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+// AuthResult is returned on successful registration or login.
+type AuthResult struct {
+	Token     string      `json:"token"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	User      *model.User `json:"user"`
+}
+
+type AuthService interface {
+	Register(ctx context.Context, name string, email string, password string) (*AuthResult, error)
+	Login(ctx context.Context, email string, password string) (*AuthResult, error)
+}
+
+type authService struct {
+	repo   repository.UserRepository
+	tokens *auth.TokenManager
+}
+
+func NewAuthService(repo repository.UserRepository, tokens *auth.TokenManager) AuthService {
+	return &authService{repo: repo, tokens: tokens}
+}
+
+func (s *authService) Register(ctx context.Context, name string, email string, password string) (*AuthResult, error) {
+	email = normalizeEmail(email)
+
+	if _, err := s.repo.GetUserByEmail(ctx, email); err == nil {
+		return nil, ErrEmailTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Name:         name,
+		Email:        email,
+		PasswordHash: string(hash),
+	}
+
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		// Someone registered the same email since the check above
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+
+	return s.issue(user)
+}
+
+func (s *authService) Login(ctx context.Context, email string, password string) (*AuthResult, error) {
+	user, err := s.repo.GetUserByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// Users without a password (e.g. created by name only) can't log in
+	if user.PasswordHash == "" {
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.issue(user)
+}
+
+func (s *authService) issue(user *model.User) (*AuthResult, error) {
+	token, expiresAt, err := s.tokens.Issue(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResult{Token: token, ExpiresAt: expiresAt, User: user}, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+)
+
+var (
+	ErrUnknownCategory = errors.New("unknown category")
+	ErrCategoryExists  = errors.New("category already exists in this group")
+	ErrInvalidCategory = errors.New("category name must not be empty")
+	ErrInvalidRange    = errors.New("the start of the date range must be before its end")
+)
+
+type CategoryService interface {
+	// GetCategories lists the built-in categories followed by the group's custom ones.
+	GetCategories(ctx context.Context, groupID uint) ([]string, error)
+	CreateCategory(ctx context.Context, groupID uint, name string) (*model.Category, error)
+	// GetCategoryReport totals the group's spending per category in [from, to). Either bound may be nil.
+	GetCategoryReport(ctx context.Context, groupID uint, from, to *time.Time) (*model.CategorySpendingReport, error)
+}
+
+type categoryService struct {
+	repo        repository.CategoryRepository
+	groupRepo   repository.GroupRepository
+	expenseRepo repository.ExpenseRepository
+}
+
+func NewCategoryService(repo repository.CategoryRepository, groupRepo repository.GroupRepository, expenseRepo repository.ExpenseRepository) CategoryService {
+	return &categoryService{repo: repo, groupRepo: groupRepo, expenseRepo: expenseRepo}
+}
+
+func (s *categoryService) GetCategories(ctx context.Context, groupID uint) ([]string, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+
+	custom, err := s.repo.GetCategoriesByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := append([]string{}, model.DefaultCategories...)
+	for _, c := range custom {
+		names = append(names, c.Name)
+	}
+	return names, nil
+}
+
+func (s *categoryService) CreateCategory(ctx context.Context, groupID uint, name string) (*model.Category, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+
+	name = normalizeCategory(name)
+	if name == "" {
+		return nil, ErrInvalidCategory
+	}
+	exists, err := categoryExists(ctx, s.repo, groupID, name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrCategoryExists
+	}
+
+	category := &model.Category{GroupID: groupID, Name: name}
+	if err := s.repo.CreateCategory(ctx, category); err != nil {
+		// Created by someone else since the check above
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrCategoryExists
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+// GetCategoryReport is computed from the expense splits rather than from who paid,
+// so each member's share reflects what they actually consumed.
+func (s *categoryService) GetCategoryReport(ctx context.Context, groupID uint, from, to *time.Time) (*model.CategorySpendingReport, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, ErrInvalidRange
+	}
+
+	group, err := getGroup(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := loadConvertedExpenses(ctx, s.expenseRepo, group)
+	if err != nil {
+		return nil, err
+	}
+
+	type categoryTotals struct {
+		total   int64
+		members map[uint]int64
+	}
+	byCategory := make(map[string]*categoryTotals)
+
+	report := &model.CategorySpendingReport{
+		GroupID:    groupID,
+		Currency:   group.BaseCurrency,
+		From:       from,
+		To:         to,
+		Categories: []model.CategoryReport{},
+	}
+	for _, exp := range expenses {
+		if from != nil && exp.CreatedAt.Before(*from) {
+			continue
+		}
+		if to != nil && !exp.CreatedAt.Before(*to) {
+			continue
+		}
+
+		name := exp.Category
+		if name == "" {
+			name = model.DefaultCategory
+		}
+		totals, ok := byCategory[name]
+		if !ok {
+			totals = &categoryTotals{members: make(map[uint]int64)}
+			byCategory[name] = totals
+		}
+		for _, split := range exp.Splits {
+			totals.total += split.Amount
+			totals.members[split.UserID] += split.Amount
+			report.Total += split.Amount
+		}
+	}
+
+	for name, totals := range byCategory {
+		entry := model.CategoryReport{Category: name, Total: totals.total, Members: []model.MemberShare{}}
+		for userID, amount := range totals.members {
+			entry.Members = append(entry.Members, model.MemberShare{UserID: userID, Amount: amount})
+		}
+		sort.Slice(entry.Members, func(i, j int) bool {
+			return entry.Members[i].UserID < entry.Members[j].UserID
+		})
+		report.Categories = append(report.Categories, entry)
+	}
+
+	// Largest spend first; ties by name so the order is stable
+	sort.Slice(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Category < b.Category
+	})
+
+	return report, nil
+}
+
+// resolveCategory defaults an empty category and makes sure the category is either
+// built in or one of the group's custom categories.
+func resolveCategory(ctx context.Context, repo repository.CategoryRepository, groupID uint, name string) (string, error) {
+	name = normalizeCategory(name)
+	if name == "" {
+		return model.DefaultCategory, nil
+	}
+
+	exists, err := categoryExists(ctx, repo, groupID, name)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", ErrUnknownCategory
+	}
+	return name, nil
+}
+
+func categoryExists(ctx context.Context, repo repository.CategoryRepository, groupID uint, name string) (bool, error) {
+	for _, builtIn := range model.DefaultCategories {
+		if name == builtIn {
+			return true, nil
+		}
+	}
+	return repo.CategoryExists(ctx, groupID, name)
+}
+
+// normalizeCategory makes category names case-insensitive.
+func normalizeCategory(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
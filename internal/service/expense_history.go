@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+
+	"expense-tracker/internal/model"
+)
+
+// expenseHistory pairs every version with the field-level changes since the version before it.
+func expenseHistory(versions []model.ExpenseVersion) []model.ExpenseHistoryEntry {
+	entries := make([]model.ExpenseHistoryEntry, len(versions))
+	var previous *model.ExpenseSnapshot
+	for i, v := range versions {
+		entries[i] = model.ExpenseHistoryEntry{
+			Version:   v.Version,
+			Action:    v.Action,
+			ActorID:   v.ActorID,
+			CreatedAt: v.CreatedAt,
+			Changes:   diffSnapshots(previous, &versions[i].Snapshot),
+			Snapshot:  v.Snapshot,
+		}
+		previous = &versions[i].Snapshot
+	}
+	return entries
+}
+
+// diffSnapshots lists the fields that differ between two snapshots, in a fixed order. With no
+// previous snapshot every field is reported as new.
+func diffSnapshots(from, to *model.ExpenseSnapshot) []model.FieldChange {
+	changes := []model.FieldChange{}
+	field := func(name string, before, after interface{}) {
+		if from == nil {
+			before = nil
+		}
+		if from != nil && reflect.DeepEqual(before, after) {
+			return
+		}
+		changes = append(changes, model.FieldChange{Field: name, From: before, To: after})
+	}
+
+	var old model.ExpenseSnapshot
+	if from != nil {
+		old = *from
+	}
+	field("payer_id", old.PayerID, to.PayerID)
+	field("amount", old.Amount, to.Amount)
+	field("description", old.Description, to.Description)
+	field("category", old.Category, to.Category)
+	field("split_type", old.SplitType, to.SplitType)
+	field("currency", old.Currency, to.Currency)
+	field("exchange_rate", old.ExchangeRate, to.ExchangeRate)
+	field("tax", old.Tax, to.Tax)
+	field("tip", old.Tip, to.Tip)
+	changes = append(changes, diffShares("payers", old.Payers, to.Payers)...)
+	changes = append(changes, diffShares("splits", old.Splits, to.Splits)...)
+	if !reflect.DeepEqual(old.Items, to.Items) {
+		changes = append(changes, model.FieldChange{Field: "items", From: nilIfEmpty(old.Items), To: nilIfEmpty(to.Items)})
+	}
+	return changes
+}
+
+// diffShares compares per-user amounts by user ID, reporting each user whose amount changed
+// as "<prefix>.<user_id>". Users who were added or removed have a nil From or To.
+func diffShares(prefix string, from, to []model.SnapshotShare) []model.FieldChange {
+	before := make(map[uint]int64, len(from))
+	for _, s := range from {
+		before[s.UserID] = s.Amount
+	}
+	after := make(map[uint]int64, len(to))
+	for _, s := range to {
+		after[s.UserID] = s.Amount
+	}
+
+	// Both lists are sorted by user ID, so merging them keeps the changes in user order
+	var changes []model.FieldChange
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		var id uint
+		switch {
+		case j == len(to) || (i < len(from) && from[i].UserID < to[j].UserID):
+			id = from[i].UserID
+			i++
+		case i == len(from) || to[j].UserID < from[i].UserID:
+			id = to[j].UserID
+			j++
+		default:
+			id = from[i].UserID
+			i++
+			j++
+		}
+
+		change := model.FieldChange{Field: fmt.Sprintf("%s.%d", prefix, id)}
+		oldAmount, hadOld := before[id]
+		newAmount, hasNew := after[id]
+		if hadOld && hasNew && oldAmount == newAmount {
+			continue
+		}
+		if hadOld {
+			change.From = oldAmount
+		}
+		if hasNew {
+			change.To = newAmount
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func nilIfEmpty(items []model.SnapshotItem) interface{} {
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
@@ -2,50 +2,368 @@ package service
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 
+	"gorm.io/gorm"
+
+	"expense-tracker/internal/currency"
 	"expense-tracker/internal/model"
 	"expense-tracker/internal/repository"
+	"expense-tracker/internal/storage"
 )
 
 var (
-	ErrSplitMismatch = errors.New("the sum of expense splits does not equal the total amount")
+	ErrSplitMismatch   = errors.New("the sum of expense splits does not equal the total amount")
+	ErrExpenseNotFound = errors.New("expense not found")
 )
 
+// ExpenseInput describes an expense to create, or the new state of an expense being edited.
+// Currency defaults to the group's base currency, Category to "other" and SplitType to exact.
+// Date backdates a new expense, e.g. when importing history; zero means now.
+// Itemized expenses take Items, Tax and Tip instead of Splits. When several people paid,
+// Payers lists each contribution and PayerID is optional; otherwise PayerID paid it all.
+type ExpenseInput struct {
+	Date        time.Time
+	PayerID     uint
+	Payers      []PayerInput
+	Amount      int64
+	Description string
+	Currency    string
+	Category    string
+	SplitType   model.SplitType
+	Splits      []SplitInput
+	Items       []ItemInput
+	Tax         int64
+	Tip         int64
+}
+
 type ExpenseService interface {
-	AddExpense(ctx context.Context, groupID uint, payerID uint, amount int64, description string, splits []model.ExpenseSplit) (*model.Expense, error)
+	AddExpense(ctx context.Context, groupID uint, input ExpenseInput) (*model.Expense, error)
+	UpdateExpense(ctx context.Context, groupID uint, expenseID uint, input ExpenseInput) (*model.Expense, error)
+	DeleteExpense(ctx context.Context, groupID uint, expenseID uint) error
+	ListExpenses(ctx context.Context, query repository.ExpenseQuery) (*repository.ExpensePage, error)
+	// GetExpenseHistory lists every version of an expense, oldest first, with the fields that
+	// changed in each. Deleted expenses keep their history.
+	GetExpenseHistory(ctx context.Context, groupID uint, expenseID uint) ([]model.ExpenseHistoryEntry, error)
+	// ExportExpenses writes the group's expenses to w as CSV, one row per split. Nothing is
+	// written to w when the caller may not read the group, and an export that fails part way
+	// ends with a row saying so.
+	ExportExpenses(ctx context.Context, groupID uint, w io.Writer) error
 }
 
 type expenseService struct {
-	repo repository.ExpenseRepository
+	repo         repository.ExpenseRepository
+	groupRepo    repository.GroupRepository
+	categoryRepo repository.CategoryRepository
+	blobs        storage.BlobStore
+	rates        currency.ExchangeRateProvider
+	activities   ActivityRecorder
+	tx           Transactor
 }
 
-func NewExpenseService(repo repository.ExpenseRepository) ExpenseService {
-	return &expenseService{repo: repo}
+func NewExpenseService(repo repository.ExpenseRepository, groupRepo repository.GroupRepository, categoryRepo repository.CategoryRepository, blobs storage.BlobStore, rates currency.ExchangeRateProvider, activities ActivityRecorder, tx Transactor) ExpenseService {
+	return &expenseService{
+		repo:         repo,
+		groupRepo:    groupRepo,
+		categoryRepo: categoryRepo,
+		blobs:        blobs,
+		rates:        rates,
+		activities:   activities,
+		tx:           tx,
+	}
 }
 
-func (s *expenseService) AddExpense(ctx context.Context, groupID uint, payerID uint, amount int64, description string, splits []model.ExpenseSplit) (*model.Expense, error) {
-	// Validate that the splits sum up to the total amount
-	var splitSum int64
-	for _, split := range splits {
-		splitSum += split.Amount
+func (s *expenseService) AddExpense(ctx context.Context, groupID uint, input ExpenseInput) (*model.Expense, error) {
+	actorID, err := requireMember(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+	group, payers, computed, err := s.validate(ctx, groupID, &input)
+	if err != nil {
+		return nil, err
 	}
 
-	if splitSum != amount {
-		return nil, ErrSplitMismatch
+	// Capture the exchange rate now so later rate changes never rewrite history
+	rate, err := s.rates.Rate(ctx, input.Currency, group.BaseCurrency)
+	if err != nil {
+		return nil, err
 	}
 
 	expense := &model.Expense{
-		GroupID:     groupID,
-		PayerID:     payerID,
-		Amount:      amount,
-		Description: description,
-		Splits:      splits,
+		GroupID:      groupID,
+		PayerID:      input.PayerID,
+		Amount:       input.Amount,
+		Description:  input.Description,
+		Category:     input.Category,
+		SplitType:    input.SplitType,
+		Currency:     input.Currency,
+		ExchangeRate: rate,
+		Tax:          input.Tax,
+		Tip:          input.Tip,
+		Payers:       payers,
+		Splits:       computed,
+		Items:        toExpenseItems(input.Items),
+		CreatedAt:    input.Date,
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateExpense(ctx, expense, actorID); err != nil {
+			return err
+		}
+		return s.activities.Record(ctx, expenseActivity(model.ActivityExpenseAdded, expense))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expense, nil
+}
+
+func (s *expenseService) UpdateExpense(ctx context.Context, groupID uint, expenseID uint, input ExpenseInput) (*model.Expense, error) {
+	actorID, err := requireMember(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+	// Re-validate with the same rules as a new expense
+	group, payers, computed, err := s.validate(ctx, groupID, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	expense, err := getGroupExpense(ctx, s.repo, groupID, expenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the originally captured rate unless the currency itself is being changed
+	if input.Currency != expense.Currency {
+		rate, err := s.rates.Rate(ctx, input.Currency, group.BaseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		expense.Currency = input.Currency
+		expense.ExchangeRate = rate
+	}
+
+	expense.PayerID = input.PayerID
+	expense.Amount = input.Amount
+	expense.Description = input.Description
+	expense.Category = input.Category
+	expense.SplitType = input.SplitType
+	expense.Tax = input.Tax
+	expense.Tip = input.Tip
+	expense.Payers = payers
+	expense.Splits = computed
+	expense.Items = toExpenseItems(input.Items)
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateExpense(ctx, expense, actorID); err != nil {
+			return err
+		}
+		return s.activities.Record(ctx, expenseActivity(model.ActivityExpenseEdited, expense))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expense, nil
+}
+
+func (s *expenseService) DeleteExpense(ctx context.Context, groupID uint, expenseID uint) error {
+	actorID, err := requireMember(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return err
+	}
+	expense, err := getGroupExpense(ctx, s.repo, groupID, expenseID)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		attachments, err := s.repo.DeleteExpense(ctx, expenseID, actorID)
+		if err != nil {
+			return err
+		}
+
+		// The attachment rows went with the expense; their files are removed once that has
+		// committed so a failed delete never leaves rows pointing at missing files. A file that
+		// can't be removed is only an orphan in storage, so it doesn't fail the request.
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			for _, a := range attachments {
+				_ = s.blobs.Delete(ctx, a.StorageKey)
+			}
+		})
+		return s.activities.Record(ctx, expenseActivity(model.ActivityExpenseDeleted, expense))
+	})
+}
+
+func (s *expenseService) ListExpenses(ctx context.Context, query repository.ExpenseQuery) (*repository.ExpensePage, error) {
+	if _, err := requireMember(ctx, s.groupRepo, query.GroupID); err != nil {
+		return nil, err
+	}
+	group, err := getGroup(ctx, s.groupRepo, query.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	query.BaseCurrency = group.BaseCurrency
+	return s.repo.ListExpenses(ctx, query)
+}
+
+func (s *expenseService) GetExpenseHistory(ctx context.Context, groupID uint, expenseID uint) ([]model.ExpenseHistoryEntry, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.CreateExpense(ctx, expense); err != nil {
+	versions, err := s.repo.GetExpenseVersions(ctx, expenseID)
+	if err != nil {
 		return nil, err
 	}
+	// The expense may be gone, so its group is taken from the history itself
+	if len(versions) == 0 || versions[0].GroupID != groupID {
+		return nil, ErrExpenseNotFound
+	}
+	return expenseHistory(versions), nil
+}
+
+// exportHeader is the first row of an expense export.
+var exportHeader = []string{"date", "expense_id", "description", "category", "payer", "participant", "amount", "share", "currency"}
+
+// exportIncomplete is the last row of an export that failed part way, so that a cut-off file
+// can't pass for a complete one.
+var exportIncomplete = []string{"# export failed; this file is incomplete"}
+
+func (s *expenseService) ExportExpenses(ctx context.Context, groupID uint, w io.Writer) error {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return err
+	}
+
+	// csv.Writer buffers a few KB at a time, so large groups are never held in memory
+	out := csv.NewWriter(w)
+	if err := out.Write(exportHeader); err != nil {
+		return err
+	}
+
+	err := s.repo.StreamExportRows(ctx, groupID, func(row repository.ExportRow) error {
+		return out.Write([]string{
+			row.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatUint(uint64(row.ExpenseID), 10),
+			spreadsheetSafe(row.Description),
+			spreadsheetSafe(row.Category),
+			spreadsheetSafe(row.PayerName),
+			spreadsheetSafe(row.ParticipantName),
+			currency.FormatAmount(row.Amount, row.Currency),
+			currency.FormatAmount(row.Share, row.Currency),
+			currency.Normalize(row.Currency),
+		})
+	})
+	if err != nil {
+		out.Write(exportIncomplete)
+		out.Flush()
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// spreadsheetSafe stops user-supplied text from being read as a formula when the export is
+// opened in a spreadsheet, by prefixing a quote to cells that start like one.
+func spreadsheetSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// validate runs every check shared by creating and editing an expense, once the caller's
+// membership has been checked. It fills in the input's defaults, and resolves the requested
+// payers and split into cent amounts that each sum up to the total amount.
+func (s *expenseService) validate(ctx context.Context, groupID uint, input *ExpenseInput) (*model.Group, []model.ExpensePayer, []model.ExpenseSplit, error) {
+	payers, payerID, err := computePayers(input.Amount, input.PayerID, input.Payers)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	input.PayerID = payerID
+
+	participants := append(payerUserIDs(payers), userIDs(input.Splits)...)
+	if input.SplitType == model.SplitTypeItemized {
+		participants = append(payerUserIDs(payers), itemUserIDs(input.Items)...)
+	}
+	if err := requireParticipants(ctx, s.groupRepo, groupID, participants...); err != nil {
+		return nil, nil, nil, err
+	}
 
+	if input.SplitType == "" {
+		input.SplitType = model.SplitTypeExact
+	}
+	var computed []model.ExpenseSplit
+	if input.SplitType == model.SplitTypeItemized {
+		computed, err = computeItemizedSplits(input.Amount, input.Items, input.Tax, input.Tip)
+	} else {
+		input.Items, input.Tax, input.Tip = nil, 0, 0
+		computed, err = computeSplits(input.Amount, input.SplitType, input.Splits)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	group, err := getGroup(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	input.Currency = resolveCurrency(input.Currency, group)
+
+	input.Category, err = resolveCategory(ctx, s.categoryRepo, groupID, input.Category)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return group, payers, computed, nil
+}
+
+// getGroupExpense loads an expense and makes sure it belongs to the given group,
+// so an expense can't be modified through another group's URL.
+func getGroupExpense(ctx context.Context, repo repository.ExpenseRepository, groupID uint, expenseID uint) (*model.Expense, error) {
+	expense, err := repo.GetExpenseByID(ctx, expenseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExpenseNotFound
+		}
+		return nil, err
+	}
+	if expense.GroupID != groupID {
+		return nil, ErrExpenseNotFound
+	}
 	return expense, nil
 }
+
+// resolveCurrency defaults an expense to the group's base currency when none was given.
+func resolveCurrency(code string, group *model.Group) string {
+	if strings.TrimSpace(code) == "" {
+		return currency.Normalize(group.BaseCurrency)
+	}
+	return currency.Normalize(code)
+}
+
+func toExpenseItems(inputs []ItemInput) []model.ExpenseItem {
+	if len(inputs) == 0 {
+		return nil
+	}
+	items := make([]model.ExpenseItem, len(inputs))
+	for i, in := range inputs {
+		items[i] = model.ExpenseItem{Name: in.Name, UnitAmount: in.UnitAmount, Quantity: in.Quantity}
+		for _, id := range in.UserIDs {
+			items[i].Assignees = append(items[i].Assignees, model.ExpenseItemAssignee{UserID: id})
+		}
+	}
+	return items
+}
+
+func participantIDs(payerID uint, splits []SplitInput) []uint {
+	return append([]uint{payerID}, userIDs(splits)...)
+}
@@ -2,31 +2,72 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/currency"
 	"expense-tracker/internal/model"
 	"expense-tracker/internal/repository"
 )
 
+var (
+	ErrGroupNotFound = errors.New("group not found")
+	ErrUserNotFound  = errors.New("one or more users do not exist")
+	ErrNotMember     = errors.New("user is not a member of this group")
+)
+
+// OutstandingBalanceError is returned when removing a member who still owes or is owed money.
+type OutstandingBalanceError struct {
+	UserID  uint
+	Balance int64 // Positive means owed money, negative means owes money
+}
+
+func (e *OutstandingBalanceError) Error() string {
+	return fmt.Sprintf("user %d still has an outstanding balance of %d cents", e.UserID, e.Balance)
+}
+
 type GroupService interface {
-	CreateGroup(ctx context.Context, title string, description string) (*model.Group, error)
+	CreateGroup(ctx context.Context, title string, description string, baseCurrency string) (*model.Group, error)
 	GetGroup(ctx context.Context, id uint) (*model.Group, error)
+	GetMembers(ctx context.Context, groupID uint) ([]model.User, error)
+	AddMembers(ctx context.Context, groupID uint, userIDs []uint) ([]model.User, error)
+	RemoveMember(ctx context.Context, groupID uint, userID uint) error
+	RenameGroup(ctx context.Context, groupID uint, title string) (*model.Group, error)
 }
 
 type groupService struct {
-	repo repository.GroupRepository
+	repo        repository.GroupRepository
+	userRepo    repository.UserRepository
+	settlements SettlementService
+	activities  ActivityRecorder
+	tx          Transactor
 }
 
-func NewGroupService(repo repository.GroupRepository) GroupService {
-	return &groupService{repo: repo}
+func NewGroupService(repo repository.GroupRepository, userRepo repository.UserRepository, settlements SettlementService, activities ActivityRecorder, tx Transactor) GroupService {
+	return &groupService{repo: repo, userRepo: userRepo, settlements: settlements, activities: activities, tx: tx}
 }
 
-func (s *groupService) CreateGroup(ctx context.Context, title string, description string) (*model.Group, error) {
+func (s *groupService) CreateGroup(ctx context.Context, title string, description string, baseCurrency string) (*model.Group, error) {
 	group := &model.Group{
-		Title:       title,
-		Description: description,
+		Title:        title,
+		Description:  description,
+		BaseCurrency: currency.Normalize(baseCurrency),
+	}
+
+	// The creator becomes the group's first member
+	creatorID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
 	}
 
-	if err := s.repo.CreateGroup(ctx, group); err != nil {
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateGroup(ctx, group, []uint{creatorID}); err != nil {
+			return err
+		}
+		return s.activities.Record(ctx, memberActivity(model.ActivityMemberJoined, group.ID, creatorID))
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -34,5 +75,153 @@ func (s *groupService) CreateGroup(ctx context.Context, title string, descriptio
 }
 
 func (s *groupService) GetGroup(ctx context.Context, id uint) (*model.Group, error) {
-	return s.repo.GetGroupByID(ctx, id)
+	if _, err := requireMember(ctx, s.repo, id); err != nil {
+		return nil, err
+	}
+
+	group, err := getGroup(ctx, s.repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Members, err = s.repo.GetMembers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (s *groupService) GetMembers(ctx context.Context, groupID uint) ([]model.User, error) {
+	if _, err := requireMember(ctx, s.repo, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(ctx, groupID)
+}
+
+func (s *groupService) AddMembers(ctx context.Context, groupID uint, userIDs []uint) ([]model.User, error) {
+	if _, err := requireMember(ctx, s.repo, groupID); err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepo.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[uint]bool, len(users))
+	for _, u := range users {
+		found[u.ID] = true
+	}
+	for _, id := range userIDs {
+		if !found[id] {
+			return nil, ErrUserNotFound
+		}
+	}
+
+	// Users who already belong to the group are skipped, and don't show up in the feed again
+	existing, err := s.repo.GetMemberIDs(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddUsersToGroup(ctx, groupID, userIDs); err != nil {
+			return err
+		}
+		for _, id := range newMemberIDs(existing, userIDs) {
+			if err := s.activities.Record(ctx, memberActivity(model.ActivityMemberJoined, groupID, id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetMembers(ctx, groupID)
+}
+
+// RemoveMember removes a user from the group, refusing while they still have a non-zero balance
+// so that nobody's debts disappear from the group's settlements. The group stays locked from the
+// balance check until the removal commits, so no expense or payment can slip in between.
+func (s *groupService) RemoveMember(ctx context.Context, groupID uint, userID uint) error {
+	if _, err := requireMember(ctx, s.repo, groupID); err != nil {
+		return err
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.LockGroup(ctx, groupID); err != nil {
+			return err
+		}
+
+		isMember, err := s.repo.IsMember(ctx, groupID, userID)
+		if err != nil {
+			return err
+		}
+		if !isMember {
+			return ErrNotMember
+		}
+
+		balances, err := s.settlements.CalculateBalances(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		for _, b := range balances {
+			if b.UserID == userID && b.Balance != 0 {
+				return &OutstandingBalanceError{UserID: userID, Balance: b.Balance}
+			}
+		}
+
+		if err := s.repo.RemoveUserFromGroup(ctx, groupID, userID); err != nil {
+			return err
+		}
+		return s.activities.Record(ctx, memberActivity(model.ActivityMemberLeft, groupID, userID))
+	})
+}
+
+func (s *groupService) RenameGroup(ctx context.Context, groupID uint, title string) (*model.Group, error) {
+	if _, err := requireMember(ctx, s.repo, groupID); err != nil {
+		return nil, err
+	}
+
+	group, err := getGroup(ctx, s.repo, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Title == title {
+		return group, nil
+	}
+
+	oldTitle := group.Title
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateGroupTitle(ctx, groupID, title); err != nil {
+			return err
+		}
+		return s.activities.Record(ctx, &model.Activity{
+			GroupID: groupID,
+			Type:    model.ActivityGroupRenamed,
+			Data:    model.ActivityData{OldTitle: oldTitle, NewTitle: title},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	group.Title = title
+	return group, nil
+}
+
+// newMemberIDs returns the distinct userIDs that aren't in existing.
+func newMemberIDs(existing []uint, userIDs []uint) []uint {
+	seen := make(map[uint]bool, len(existing)+len(userIDs))
+	for _, id := range existing {
+		seen[id] = true
+	}
+	var added []uint
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			added = append(added, id)
+		}
+	}
+	return added
 }
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"expense-tracker/internal/currency"
+	"expense-tracker/internal/importer"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+)
+
+var (
+	ErrAmbiguousName = errors.New("more than one group member has this name")
+	ErrInvalidAmount = errors.New("amount must be positive")
+)
+
+// Statuses of an imported row.
+const (
+	ImportStatusOK     = "ok"
+	ImportStatusFailed = "failed"
+)
+
+type ImportService interface {
+	// Import inserts parsed records into a group. People are matched to group members by name,
+	// case-insensitively, and unknown names become new users who are added to the group. Every
+	// expense goes through ExpenseService.AddExpense, so it gets the same validation as one
+	// entered by hand. Rows fail independently, but the import as a whole commits or rolls back
+	// at once, so one that fails can be run again; a dry run reports what would happen without
+	// writing anything.
+	Import(ctx context.Context, groupID uint, records []importer.Record, dryRun bool) (*model.ImportReport, error)
+}
+
+type importService struct {
+	groupRepo    repository.GroupRepository
+	userRepo     repository.UserRepository
+	paymentRepo  repository.PaymentRepository
+	categoryRepo repository.CategoryRepository
+	expenses     ExpenseService
+	rates        currency.ExchangeRateProvider
+	activities   ActivityRecorder
+	tx           Transactor
+}
+
+func NewImportService(groupRepo repository.GroupRepository, userRepo repository.UserRepository, paymentRepo repository.PaymentRepository, categoryRepo repository.CategoryRepository, expenses ExpenseService, rates currency.ExchangeRateProvider, activities ActivityRecorder, tx Transactor) ImportService {
+	return &importService{
+		groupRepo:    groupRepo,
+		userRepo:     userRepo,
+		paymentRepo:  paymentRepo,
+		categoryRepo: categoryRepo,
+		expenses:     expenses,
+		rates:        rates,
+		activities:   activities,
+		tx:           tx,
+	}
+}
+
+func (s *importService) Import(ctx context.Context, groupID uint, records []importer.Record, dryRun bool) (*model.ImportReport, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+
+	group, err := getGroup(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.groupRepo.GetMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make(map[string]uint, len(members))
+	ambiguous := make(map[string]bool)
+	for _, m := range members {
+		key := nameKey(m.Name)
+		if _, ok := userIDs[key]; ok {
+			ambiguous[key] = true
+		}
+		userIDs[key] = m.ID
+	}
+
+	report := &model.ImportReport{DryRun: dryRun, UsersCreated: []string{}, Rows: make([]model.ImportRowResult, len(records))}
+	valid := make([]bool, len(records))
+	for i := range records {
+		records[i].ResolveCurrency(group.BaseCurrency)
+		rec := records[i]
+		report.Rows[i] = model.ImportRowResult{Line: rec.Line, Description: rec.Description}
+		if err := s.check(ctx, group, rec, ambiguous); err != nil {
+			report.Rows[i].Status = ImportStatusFailed
+			report.Rows[i].Error = err.Error()
+			continue
+		}
+		valid[i] = true
+
+		for _, name := range rec.Names() {
+			if _, ok := userIDs[nameKey(name)]; !ok {
+				userIDs[nameKey(name)] = 0
+				report.UsersCreated = append(report.UsersCreated, name)
+			}
+		}
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if !dryRun {
+			if err := s.createUsers(ctx, groupID, report.UsersCreated, userIDs); err != nil {
+				return err
+			}
+		}
+
+		for i, rec := range records {
+			if !valid[i] {
+				report.Failed++
+				continue
+			}
+			if !dryRun {
+				// A row that fails is rolled back on its own, leaving the rest of the import
+				err := s.tx.InTx(ctx, func(ctx context.Context) error {
+					return s.insert(ctx, group, rec, userIDs, &report.Rows[i])
+				})
+				if err != nil {
+					report.Rows[i] = model.ImportRowResult{Line: rec.Line, Description: rec.Description, Status: ImportStatusFailed, Error: err.Error()}
+					report.Failed++
+					continue
+				}
+			}
+			report.Rows[i].Status = ImportStatusOK
+			report.Imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// check validates a record without touching the database, using the same split rules as AddExpense.
+func (s *importService) check(ctx context.Context, group *model.Group, rec importer.Record, ambiguous map[string]bool) error {
+	if rec.Err != nil {
+		return rec.Err
+	}
+	if rec.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	for _, name := range rec.Names() {
+		if ambiguous[nameKey(name)] {
+			return ErrAmbiguousName
+		}
+	}
+
+	if rec.Payment {
+		if len(rec.Shares) != 1 || nameKey(rec.Payer) == nameKey(rec.Shares[0].Name) {
+			return ErrSelfPayment
+		}
+		_, err := s.paymentAmount(ctx, group, rec)
+		return err
+	}
+
+	// Stand-in user IDs are enough to run the split rules before the users exist
+	placeholders := make(map[string]uint)
+	inputs := make([]SplitInput, len(rec.Shares))
+	for i, share := range rec.Shares {
+		id, ok := placeholders[nameKey(share.Name)]
+		if !ok {
+			id = uint(len(placeholders) + 1)
+			placeholders[nameKey(share.Name)] = id
+		}
+		inputs[i] = SplitInput{UserID: id, Amount: share.Amount}
+	}
+	if _, err := computeSplits(rec.Amount, rec.SplitType, inputs); err != nil {
+		return err
+	}
+
+	_, err := s.rates.Rate(ctx, rec.Currency, currency.Normalize(group.BaseCurrency))
+	return err
+}
+
+func (s *importService) createUsers(ctx context.Context, groupID uint, names []string, userIDs map[string]uint) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(names))
+	for i, name := range names {
+		user := &model.User{Name: strings.TrimSpace(name)}
+		if err := s.userRepo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		userIDs[nameKey(name)] = user.ID
+		ids[i] = user.ID
+	}
+	if err := s.groupRepo.AddUsersToGroup(ctx, groupID, ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.activities.Record(ctx, memberActivity(model.ActivityMemberJoined, groupID, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *importService) insert(ctx context.Context, group *model.Group, rec importer.Record, userIDs map[string]uint, result *model.ImportRowResult) error {
+	groupID := group.ID
+	if rec.Payment {
+		amount, err := s.paymentAmount(ctx, group, rec)
+		if err != nil {
+			return err
+		}
+		payment := &model.Payment{
+			GroupID:    groupID,
+			FromUserID: userIDs[nameKey(rec.Payer)],
+			ToUserID:   userIDs[nameKey(rec.Shares[0].Name)],
+			Amount:     amount,
+			Note:       rec.Description,
+			CreatedAt:  rec.Date,
+		}
+		if err := s.paymentRepo.CreatePayment(ctx, payment); err != nil {
+			return err
+		}
+		result.PaymentID = payment.ID
+		return s.activities.Record(ctx, paymentActivity(payment, group.BaseCurrency))
+	}
+
+	// Categories from other tools rarely match ours, so unknown ones fall back to the default
+	category, err := resolveCategory(ctx, s.categoryRepo, groupID, rec.Category)
+	if err == ErrUnknownCategory {
+		category = model.DefaultCategory
+	} else if err != nil {
+		return err
+	}
+
+	splits := make([]SplitInput, len(rec.Shares))
+	for i, share := range rec.Shares {
+		splits[i] = SplitInput{UserID: userIDs[nameKey(share.Name)], Amount: share.Amount}
+	}
+
+	expense, err := s.expenses.AddExpense(ctx, groupID, ExpenseInput{
+		Date:        rec.Date,
+		PayerID:     userIDs[nameKey(rec.Payer)],
+		Amount:      rec.Amount,
+		Description: rec.Description,
+		Currency:    rec.Currency,
+		Category:    category,
+		SplitType:   rec.SplitType,
+		Splits:      splits,
+	})
+	if err != nil {
+		return err
+	}
+	result.ExpenseID = expense.ID
+	return nil
+}
+
+// paymentAmount converts an imported payment into the group's base currency, which is the
+// only currency payments are kept in.
+func (s *importService) paymentAmount(ctx context.Context, group *model.Group, rec importer.Record) (int64, error) {
+	base := currency.Normalize(group.BaseCurrency)
+	rate, err := s.rates.Rate(ctx, rec.Currency, base)
+	if err != nil {
+		return 0, err
+	}
+	amount := currency.Convert(rec.Amount, rec.Currency, base, rate)
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	return amount, nil
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"sort"
+
+	"expense-tracker/internal/model"
+)
+
+// groupLedger holds everything that contributes to a group's balances. Expense amounts and
+// splits have already been converted into the group's base currency.
+type groupLedger struct {
+	group    *model.Group
+	expenses []model.Expense
+	payments []model.Payment
+}
+
+// balances collapses the ledger into one net balance per user, sorted by user ID.
+// Positive balance = person is owed money, negative balance = person owes money.
+func (l *groupLedger) balances() []model.UserBalance {
+	balancesMap := make(map[uint]int64)
+
+	for _, exp := range l.expenses {
+		// Add what each payer paid (they are owed this money)
+		for _, payer := range paidBy(exp) {
+			balancesMap[payer.UserID] += payer.Amount
+		}
+
+		// Subtract what each person owes (their share of the expense)
+		for _, split := range exp.Splits {
+			balancesMap[split.UserID] -= split.Amount
+		}
+	}
+
+	// Fold in recorded payments: the payer has reduced their debt, the receiver has been paid back
+	for _, p := range l.payments {
+		balancesMap[p.FromUserID] += p.Amount
+		balancesMap[p.ToUserID] -= p.Amount
+	}
+
+	var userBalances []model.UserBalance
+	for userID, balance := range balancesMap {
+		if balance != 0 {
+			userBalances = append(userBalances, model.UserBalance{
+				UserID:  userID,
+				Balance: balance,
+			})
+		}
+	}
+
+	sort.Slice(userBalances, func(i, j int) bool {
+		return userBalances[i].UserID < userBalances[j].UserID
+	})
+	return userBalances
+}
+
+// pairwiseDebts derives who owes whom directly from the payer-to-split relationships, before
+// any simplification. When several people paid, each split is owed to them in proportion to
+// what they paid. Debts in both directions between the same two people are netted, and
+// recorded payments reduce the payer's debt to the receiver.
+func (l *groupLedger) pairwiseDebts() []model.Debt {
+	type pair struct{ low, high uint }
+	// net[pair] > 0 means low owes high, < 0 means high owes low
+	net := make(map[pair]int64)
+	owe := func(from, to uint, amount int64) {
+		if from == to {
+			return
+		}
+		if from < to {
+			net[pair{from, to}] += amount
+		} else {
+			net[pair{to, from}] -= amount
+		}
+	}
+
+	for _, exp := range l.expenses {
+		payers := paidBy(exp)
+		weights := make([]int64, len(payers))
+		ids := make([]uint, len(payers))
+		for i, payer := range payers {
+			weights[i] = payer.Amount
+			ids[i] = payer.UserID
+		}
+		for _, split := range exp.Splits {
+			if len(payers) == 1 {
+				owe(split.UserID, payers[0].UserID, split.Amount)
+				continue
+			}
+			for i, share := range allocateByWeights(split.Amount, weights, ids) {
+				owe(split.UserID, ids[i], share)
+			}
+		}
+	}
+	for _, p := range l.payments {
+		owe(p.FromUserID, p.ToUserID, -p.Amount)
+	}
+
+	var debts []model.Debt
+	for p, amount := range net {
+		switch {
+		case amount > 0:
+			debts = append(debts, model.Debt{FromUserID: p.low, ToUserID: p.high, Amount: amount})
+		case amount < 0:
+			debts = append(debts, model.Debt{FromUserID: p.high, ToUserID: p.low, Amount: -amount})
+		}
+	}
+
+	sortDebts(debts)
+	return debts
+}
+
+func sortDebts(debts []model.Debt) {
+	sort.Slice(debts, func(i, j int) bool {
+		if debts[i].FromUserID != debts[j].FromUserID {
+			return debts[i].FromUserID < debts[j].FromUserID
+		}
+		return debts[i].ToUserID < debts[j].ToUserID
+	})
+}
+
+// contributions lists every expense and payment that changed the balance of either user,
+// with the effect it had on each of them.
+func (l *groupLedger) contributions(fromUserID, toUserID uint) []model.BalanceContribution {
+	var result []model.BalanceContribution
+
+	for _, exp := range l.expenses {
+		effects := make(map[uint]int64)
+		for _, payer := range paidBy(exp) {
+			effects[payer.UserID] += payer.Amount
+		}
+		for _, split := range exp.Splits {
+			effects[split.UserID] -= split.Amount
+		}
+		if effects[fromUserID] == 0 && effects[toUserID] == 0 {
+			continue
+		}
+		result = append(result, model.BalanceContribution{
+			Kind:           "expense",
+			ID:             exp.ID,
+			Description:    exp.Description,
+			FromUserEffect: effects[fromUserID],
+			ToUserEffect:   effects[toUserID],
+		})
+	}
+
+	for _, p := range l.payments {
+		effects := map[uint]int64{p.FromUserID: p.Amount}
+		effects[p.ToUserID] -= p.Amount
+		if effects[fromUserID] == 0 && effects[toUserID] == 0 {
+			continue
+		}
+		result = append(result, model.BalanceContribution{
+			Kind:           "payment",
+			ID:             p.ID,
+			Description:    p.Note,
+			FromUserEffect: effects[fromUserID],
+			ToUserEffect:   effects[toUserID],
+		})
+	}
+
+	return result
+}
+
+// paidBy returns who paid an expense and how much. Expenses loaded without their payer rows
+// fall back to PayerID having paid the whole amount.
+func paidBy(exp model.Expense) []model.ExpensePayer {
+	if len(exp.Payers) == 0 {
+		return []model.ExpensePayer{{ExpenseID: exp.ID, UserID: exp.PayerID, Amount: exp.Amount}}
+	}
+	return exp.Payers
+}
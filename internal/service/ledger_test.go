@@ -0,0 +1,95 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"expense-tracker/internal/model"
+)
+
+func TestPairwiseDebts(t *testing.T) {
+	splits := func(amounts map[uint]int64) []model.ExpenseSplit {
+		var result []model.ExpenseSplit
+		for id, amount := range amounts {
+			result = append(result, model.ExpenseSplit{UserID: id, Amount: amount})
+		}
+		return result
+	}
+
+	for _, tc := range []struct {
+		name     string
+		expenses []model.Expense
+		payments []model.Payment
+		want     []model.Debt
+	}{
+		{
+			"debts in both directions are netted",
+			[]model.Expense{
+				{PayerID: 1, Amount: 100, Splits: splits(map[uint]int64{1: 50, 2: 50})},
+				{PayerID: 2, Amount: 60, Splits: splits(map[uint]int64{1: 30, 2: 30})},
+			},
+			nil,
+			[]model.Debt{{FromUserID: 2, ToUserID: 1, Amount: 20}},
+		},
+		{
+			"debts that cancel out disappear",
+			[]model.Expense{
+				{PayerID: 1, Amount: 100, Splits: splits(map[uint]int64{2: 100})},
+				{PayerID: 2, Amount: 100, Splits: splits(map[uint]int64{1: 100})},
+			},
+			nil,
+			nil,
+		},
+		{
+			"splits are owed to several payers in proportion",
+			[]model.Expense{{
+				PayerID: 1, Amount: 100,
+				Payers: []model.ExpensePayer{{UserID: 1, Amount: 60}, {UserID: 2, Amount: 40}},
+				Splits: splits(map[uint]int64{1: 50, 3: 50}),
+			}},
+			nil,
+			[]model.Debt{
+				{FromUserID: 1, ToUserID: 2, Amount: 20},
+				{FromUserID: 3, ToUserID: 1, Amount: 30},
+				{FromUserID: 3, ToUserID: 2, Amount: 20},
+			},
+		},
+		{
+			"a payment reduces the debt",
+			[]model.Expense{{PayerID: 1, Amount: 100, Splits: splits(map[uint]int64{2: 100})}},
+			[]model.Payment{{FromUserID: 2, ToUserID: 1, Amount: 40}},
+			[]model.Debt{{FromUserID: 2, ToUserID: 1, Amount: 60}},
+		},
+		{
+			"overpaying turns the debt around",
+			[]model.Expense{{PayerID: 1, Amount: 100, Splits: splits(map[uint]int64{2: 100})}},
+			[]model.Payment{{FromUserID: 2, ToUserID: 1, Amount: 150}},
+			[]model.Debt{{FromUserID: 1, ToUserID: 2, Amount: 50}},
+		},
+		{
+			"a payment without expenses",
+			nil,
+			[]model.Payment{{FromUserID: 4, ToUserID: 3, Amount: 25}},
+			[]model.Debt{{FromUserID: 3, ToUserID: 4, Amount: 25}},
+		},
+	} {
+		ledger := &groupLedger{expenses: tc.expenses, payments: tc.payments}
+		got := ledger.pairwiseDebts()
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+
+		// Every user's debts add up to their balance
+		net := make(map[uint]int64)
+		for _, debt := range got {
+			net[debt.FromUserID] -= debt.Amount
+			net[debt.ToUserID] += debt.Amount
+		}
+		for _, b := range ledger.balances() {
+			if net[b.UserID] != b.Balance {
+				t.Errorf("%s: user %d has debts netting to %d but a balance of %d", tc.name, b.UserID, net[b.UserID], b.Balance)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+)
+
+var (
+	ErrUnauthenticated      = errors.New("authentication required")
+	ErrNotGroupMember       = errors.New("you are not a member of this group")
+	ErrParticipantNotMember = errors.New("all payers and split users must be members of the group")
+	ErrForbidden            = errors.New("you are not allowed to access this resource")
+)
+
+// requireMember checks that the authenticated caller belongs to the group and returns their user ID.
+// Every group-scoped read or write goes through this check.
+func requireMember(ctx context.Context, groups repository.GroupRepository, groupID uint) (uint, error) {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+
+	isMember, err := groups.IsMember(ctx, groupID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !isMember {
+		return 0, ErrNotGroupMember
+	}
+	return userID, nil
+}
+
+// requireParticipants checks that every given user belongs to the group.
+func requireParticipants(ctx context.Context, groups repository.GroupRepository, groupID uint, userIDs ...uint) error {
+	memberIDs, err := groups.GetMemberIDs(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	members := make(map[uint]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		members[id] = true
+	}
+	for _, id := range userIDs {
+		if !members[id] {
+			return ErrParticipantNotMember
+		}
+	}
+	return nil
+}
+
+// getGroup loads a group, reporting a missing one as ErrGroupNotFound.
+func getGroup(ctx context.Context, groups repository.GroupRepository, groupID uint) (*model.Group, error) {
+	group, err := groups.GetGroupByID(ctx, groupID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGroupNotFound
+	}
+	return group, err
+}
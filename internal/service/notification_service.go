@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/notify"
+	"expense-tracker/internal/repository"
+)
+
+// The weekly digest goes out on Mondays from this hour, UTC.
+const digestHour = 8
+
+type NotificationService interface {
+	ActivityListener
+	// GetPreferences returns the caller's email preferences.
+	GetPreferences(ctx context.Context, userID uint) (*model.NotificationPreferences, error)
+	// UpdatePreferences replaces the caller's email preferences.
+	UpdatePreferences(ctx context.Context, prefs *model.NotificationPreferences) (*model.NotificationPreferences, error)
+	// SendWeeklyDigests sends this week's digest to every user who hasn't had it yet, once it
+	// is due, and returns how many were sent. A digest that can't be sent is logged and tried
+	// again on the next call. It is safe to call concurrently and repeatedly.
+	SendWeeklyDigests(ctx context.Context, now time.Time) (int, error)
+}
+
+type notificationService struct {
+	repo        repository.NotificationRepository
+	userRepo    repository.UserRepository
+	groupRepo   repository.GroupRepository
+	expenseRepo repository.ExpenseRepository
+	settlements SettlementService
+	sender      notify.Sender
+	outbox      *notify.Outbox
+	logger      *slog.Logger
+}
+
+// NewNotificationService sends event emails through the outbox, so that requests don't wait
+// on the mail server, and digests straight through the sender from the background worker.
+func NewNotificationService(repo repository.NotificationRepository, userRepo repository.UserRepository, groupRepo repository.GroupRepository, expenseRepo repository.ExpenseRepository, settlements SettlementService, sender notify.Sender, outbox *notify.Outbox, logger *slog.Logger) NotificationService {
+	return &notificationService{
+		repo:        repo,
+		userRepo:    userRepo,
+		groupRepo:   groupRepo,
+		expenseRepo: expenseRepo,
+		settlements: settlements,
+		sender:      sender,
+		outbox:      outbox,
+		logger:      logger,
+	}
+}
+
+func (s *notificationService) GetPreferences(ctx context.Context, userID uint) (*model.NotificationPreferences, error) {
+	callerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if callerID != userID {
+		return nil, ErrForbidden
+	}
+
+	prefs, err := s.repo.GetPreferences(ctx, []uint{userID})
+	if err != nil {
+		return nil, err
+	}
+	p := prefs[userID]
+	return &p, nil
+}
+
+func (s *notificationService) UpdatePreferences(ctx context.Context, prefs *model.NotificationPreferences) (*model.NotificationPreferences, error) {
+	callerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if callerID != prefs.UserID {
+		return nil, ErrForbidden
+	}
+	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// OnActivity emails the people an expense was split with and the receiver of a payment,
+// except whoever made the change and anyone who opted out. Emails are best-effort, so
+// failures are logged rather than returned.
+func (s *notificationService) OnActivity(ctx context.Context, activity *model.Activity) error {
+	var err error
+	switch activity.Type {
+	case model.ActivityExpenseAdded:
+		err = s.notifyExpenseAdded(ctx, activity)
+	case model.ActivityPaymentRecorded:
+		err = s.notifyPaymentReceived(ctx, activity)
+	}
+	if err != nil {
+		s.logger.Error("failed to send activity emails", slog.Uint64("activity_id", uint64(activity.ID)), slog.String("type", string(activity.Type)), slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+func (s *notificationService) notifyExpenseAdded(ctx context.Context, activity *model.Activity) error {
+	expense, err := s.expenseRepo.GetExpenseByID(ctx, *activity.ExpenseID)
+	if err != nil {
+		return err
+	}
+
+	shares := make(map[uint]int64, len(expense.Splits))
+	var recipientIDs []uint
+	for _, split := range expense.Splits {
+		if split.UserID != activity.ActorID && split.Amount > 0 {
+			shares[split.UserID] = split.Amount
+			recipientIDs = append(recipientIDs, split.UserID)
+		}
+	}
+	if len(recipientIDs) == 0 {
+		return nil
+	}
+
+	recipients, err := s.recipients(ctx, recipientIDs, func(p model.NotificationPreferences) bool { return p.ExpenseAdded })
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	actor, group, err := s.actorAndGroup(ctx, activity)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range recipients {
+		msg, err := notify.ExpenseAddedEmail(u.Email, notify.ExpenseAddedData{
+			RecipientName: u.Name,
+			ActorName:     actor.Name,
+			GroupTitle:    group.Title,
+			Description:   expense.Description,
+			Amount:        expense.Amount,
+			Share:         shares[u.ID],
+			Currency:      expense.Currency,
+		})
+		if err != nil {
+			return err
+		}
+		s.outbox.Enqueue(msg)
+	}
+	return nil
+}
+
+func (s *notificationService) notifyPaymentReceived(ctx context.Context, activity *model.Activity) error {
+	// Someone recording a payment they received themselves doesn't need telling
+	if *activity.UserID == activity.ActorID {
+		return nil
+	}
+
+	recipients, err := s.recipients(ctx, []uint{*activity.UserID}, func(p model.NotificationPreferences) bool { return p.PaymentReceived })
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	actor, group, err := s.actorAndGroup(ctx, activity)
+	if err != nil {
+		return err
+	}
+
+	msg, err := notify.PaymentReceivedEmail(recipients[0].Email, notify.PaymentReceivedData{
+		RecipientName: recipients[0].Name,
+		ActorName:     actor.Name,
+		GroupTitle:    group.Title,
+		Amount:        activity.Data.Amount,
+		Currency:      activity.Data.Currency,
+		Note:          activity.Data.Description,
+	})
+	if err != nil {
+		return err
+	}
+	s.outbox.Enqueue(msg)
+	return nil
+}
+
+// recipients loads the users who have an email address and want the kind of email.
+func (s *notificationService) recipients(ctx context.Context, userIDs []uint, wants func(model.NotificationPreferences) bool) ([]model.User, error) {
+	prefs, err := s.repo.GetPreferences(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.userRepo.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := users[:0]
+	for _, u := range users {
+		if u.Email != "" && wants(prefs[u.ID]) {
+			recipients = append(recipients, u)
+		}
+	}
+	return recipients, nil
+}
+
+func (s *notificationService) actorAndGroup(ctx context.Context, activity *model.Activity) (*model.User, *model.Group, error) {
+	actor, err := s.userRepo.GetUserByID(ctx, activity.ActorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	group, err := getGroup(ctx, s.groupRepo, activity.GroupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return actor, group, nil
+}
+
+func (s *notificationService) SendWeeklyDigests(ctx context.Context, now time.Time) (int, error) {
+	week := weekStart(now)
+	if now.Before(week.Add(digestHour * time.Hour)) {
+		return 0, nil
+	}
+
+	users, err := s.repo.GetDigestRecipients(ctx, week)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range users {
+		if s.sendDigest(ctx, &users[i], week) {
+			sent++
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// sendDigest claims the user's digest for the week and sends it, and reports whether an email
+// went out. Users who are settled up everywhere get no email, but their digest still counts
+// as done. A digest that fails is logged and released, so that the next run tries again.
+func (s *notificationService) sendDigest(ctx context.Context, user *model.User, week time.Time) bool {
+	claimed, err := s.repo.ClaimDigest(ctx, user.ID, week)
+	if err != nil {
+		s.logger.Error("failed to claim weekly digest", slog.Uint64("user_id", uint64(user.ID)), slog.String("error", err.Error()))
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	msg, err := s.digest(ctx, user)
+	if err == nil && msg != nil {
+		err = s.sender.Send(ctx, *msg)
+	}
+	if err == nil {
+		return msg != nil
+	}
+
+	s.logger.Error("failed to send weekly digest", slog.Uint64("user_id", uint64(user.ID)), slog.String("error", err.Error()))
+	// The run may have been cancelled, e.g. on shutdown, and the claim must go either way
+	if err := s.repo.ReleaseDigest(context.WithoutCancel(ctx), user.ID, week); err != nil {
+		s.logger.Error("failed to release weekly digest", slog.Uint64("user_id", uint64(user.ID)), slog.String("error", err.Error()))
+	}
+	return false
+}
+
+// digest renders the user's digest from their balance in each group, or returns nil when
+// there is nothing to tell them.
+func (s *notificationService) digest(ctx context.Context, user *model.User) (*notify.Message, error) {
+	groups, err := s.groupRepo.GetGroupsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Balances are read as the user, who is a member of each of these groups
+	userCtx := auth.WithUserID(ctx, user.ID)
+	var entries []notify.DigestGroup
+	for _, g := range groups {
+		balances, err := s.settlements.CalculateBalances(userCtx, g.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range balances {
+			if b.UserID == user.ID && b.Balance != 0 {
+				entries = append(entries, notify.DigestGroup{Title: g.Title, Balance: b.Balance, Currency: g.BaseCurrency})
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	msg, err := notify.WeeklyDigestEmail(user.Email, notify.DigestData{RecipientName: user.Name, Groups: entries})
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// weekStart is midnight UTC on the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+)
+
+var (
+	ErrSelfPayment = errors.New("a payment must be made to a different user")
+)
+
+type PaymentService interface {
+	RecordPayment(ctx context.Context, groupID uint, fromUserID uint, toUserID uint, amount int64, note string) (*model.Payment, error)
+}
+
+type paymentService struct {
+	repo       repository.PaymentRepository
+	groupRepo  repository.GroupRepository
+	activities ActivityRecorder
+	tx         Transactor
+}
+
+func NewPaymentService(repo repository.PaymentRepository, groupRepo repository.GroupRepository, activities ActivityRecorder, tx Transactor) PaymentService {
+	return &paymentService{repo: repo, groupRepo: groupRepo, activities: activities, tx: tx}
+}
+
+func (s *paymentService) RecordPayment(ctx context.Context, groupID uint, fromUserID uint, toUserID uint, amount int64, note string) (*model.Payment, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+	if fromUserID == toUserID {
+		return nil, ErrSelfPayment
+	}
+	if err := requireParticipants(ctx, s.groupRepo, groupID, fromUserID, toUserID); err != nil {
+		return nil, err
+	}
+
+	payment := &model.Payment{
+		GroupID:    groupID,
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     amount,
+		Note:       note,
+	}
+
+	// Payments are always in the group's base currency
+	group, err := getGroup(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreatePayment(ctx, payment); err != nil {
+			return err
+		}
+		return s.activities.Record(ctx, paymentActivity(payment, group.BaseCurrency))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
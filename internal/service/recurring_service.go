@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/currency"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+)
+
+var (
+	ErrInvalidRecurrence        = errors.New("invalid recurrence: check frequency, interval, day of month and end date")
+	ErrRecurringExpenseNotFound = errors.New("recurring expense not found")
+)
+
+type RecurringExpenseService interface {
+	CreateRecurringExpense(ctx context.Context, recurring *model.RecurringExpense) (*model.RecurringExpense, error)
+	GetRecurringExpenses(ctx context.Context, groupID uint) ([]model.RecurringExpense, error)
+	DeleteRecurringExpense(ctx context.Context, groupID uint, recurringID uint) error
+	// MaterializeDue turns every occurrence due at or before now into a regular expense.
+	// It is safe to call concurrently and repeatedly; each occurrence is created at most once.
+	MaterializeDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type recurringExpenseService struct {
+	repo         repository.RecurringExpenseRepository
+	groupRepo    repository.GroupRepository
+	categoryRepo repository.CategoryRepository
+	expenses     ExpenseService
+	rates        currency.ExchangeRateProvider
+	tx           Transactor
+}
+
+func NewRecurringExpenseService(repo repository.RecurringExpenseRepository, groupRepo repository.GroupRepository, categoryRepo repository.CategoryRepository, expenses ExpenseService, rates currency.ExchangeRateProvider, tx Transactor) RecurringExpenseService {
+	return &recurringExpenseService{repo: repo, groupRepo: groupRepo, categoryRepo: categoryRepo, expenses: expenses, rates: rates, tx: tx}
+}
+
+func (s *recurringExpenseService) CreateRecurringExpense(ctx context.Context, recurring *model.RecurringExpense) (*model.RecurringExpense, error) {
+	callerID, err := requireMember(ctx, s.groupRepo, recurring.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := recurringSplitInputs(recurring.Splits)
+	if err := requireParticipants(ctx, s.groupRepo, recurring.GroupID, participantIDs(recurring.PayerID, inputs)...); err != nil {
+		return nil, err
+	}
+
+	if recurring.SplitType == "" {
+		recurring.SplitType = model.SplitTypeExact
+	}
+	// Validate the split up front so that a broken template never reaches the scheduler
+	if _, err := computeSplits(recurring.Amount, recurring.SplitType, inputs); err != nil {
+		return nil, err
+	}
+	if recurring.Category, err = resolveCategory(ctx, s.categoryRepo, recurring.GroupID, recurring.Category); err != nil {
+		return nil, err
+	}
+
+	// An empty currency follows the group's base currency; any other needs a rate into it
+	if recurring.Currency = strings.TrimSpace(recurring.Currency); recurring.Currency != "" {
+		group, err := getGroup(ctx, s.groupRepo, recurring.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		recurring.Currency = currency.Normalize(recurring.Currency)
+		if _, err := s.rates.Rate(ctx, recurring.Currency, group.BaseCurrency); err != nil {
+			return nil, err
+		}
+	}
+
+	if recurring.Interval == 0 {
+		recurring.Interval = 1
+	}
+	if recurring.DayOfMonth == 0 && (recurring.Frequency == model.FrequencyMonthly || recurring.Frequency == model.FrequencyYearly) {
+		recurring.DayOfMonth = recurring.StartDate.Day()
+	}
+	if err := validateRecurrence(recurring); err != nil {
+		return nil, err
+	}
+
+	recurring.CreatedByID = callerID
+	recurring.NextRunAt = firstOccurrence(recurring)
+	recurring.Active = recurring.EndDate == nil || !recurring.NextRunAt.After(*recurring.EndDate)
+
+	if err := s.repo.CreateRecurringExpense(ctx, recurring); err != nil {
+		return nil, err
+	}
+
+	return recurring, nil
+}
+
+func (s *recurringExpenseService) GetRecurringExpenses(ctx context.Context, groupID uint) ([]model.RecurringExpense, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRecurringExpensesByGroupID(ctx, groupID)
+}
+
+func (s *recurringExpenseService) DeleteRecurringExpense(ctx context.Context, groupID uint, recurringID uint) error {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return err
+	}
+
+	recurring, err := s.repo.GetRecurringExpenseByID(ctx, recurringID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecurringExpenseNotFound
+		}
+		return err
+	}
+	if recurring.GroupID != groupID {
+		return ErrRecurringExpenseNotFound
+	}
+
+	return s.repo.DeleteRecurringExpense(ctx, recurringID)
+}
+
+func (s *recurringExpenseService) MaterializeDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.GetDueRecurringExpenses(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	var errs []error
+	for i := range due {
+		n, err := s.materialize(ctx, &due[i], now)
+		created += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring expense %d: %w", due[i].ID, err))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return created, errors.Join(errs...)
+}
+
+// materialize creates every missed occurrence of one template, catching up after downtime.
+func (s *recurringExpenseService) materialize(ctx context.Context, recurring *model.RecurringExpense, now time.Time) (int, error) {
+	inputs := recurringSplitInputs(recurring.Splits)
+
+	created := 0
+	for !recurring.NextRunAt.After(now) && ctx.Err() == nil {
+		occurrence := recurring.NextRunAt
+		next := nextOccurrence(recurring, occurrence)
+		active := recurring.EndDate == nil || !next.After(*recurring.EndDate)
+
+		// Claiming, creating and completing an occurrence commit together, so a failure or a
+		// shutdown part way leaves it unclaimed for the next run
+		var expenseCreated bool
+		err := s.tx.InTx(ctx, func(ctx context.Context) error {
+			claimed, err := s.repo.ClaimOccurrence(ctx, recurring.ID, occurrence)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				// Already materialised by an earlier run; just move the template forward
+				return s.repo.AdvanceRecurringExpense(ctx, recurring.ID, next, active)
+			}
+
+			// Occurrences are created on behalf of whoever set up the template
+			expense, err := s.expenses.AddExpense(auth.WithUserID(ctx, recurring.CreatedByID), recurring.GroupID, ExpenseInput{
+				PayerID:     recurring.PayerID,
+				Amount:      recurring.Amount,
+				Description: recurring.Description,
+				Currency:    recurring.Currency,
+				Category:    recurring.Category,
+				SplitType:   recurring.SplitType,
+				Splits:      inputs,
+			})
+			if err != nil {
+				return err
+			}
+			expenseCreated = true
+			return s.repo.CompleteOccurrence(ctx, recurring.ID, occurrence, expense.ID, next, active)
+		})
+		if err != nil {
+			if isPermanentRecurringError(err) {
+				// The template can never succeed again (e.g. its creator left the group), so stop it
+				return created, errors.Join(err, s.repo.AdvanceRecurringExpense(ctx, recurring.ID, occurrence, false))
+			}
+			return created, err
+		}
+		if expenseCreated {
+			created++
+		}
+
+		recurring.NextRunAt = next
+		if !active {
+			break
+		}
+	}
+
+	return created, nil
+}
+
+func isPermanentRecurringError(err error) bool {
+	return err == ErrNotGroupMember || err == ErrParticipantNotMember || err == ErrUnknownCategory ||
+		errors.Is(err, currency.ErrUnsupportedCurrency) || isSplitError(err)
+}
+
+func isSplitError(err error) bool {
+	return err == ErrSplitMismatch || err == ErrInvalidSplit || err == ErrInvalidSplitType || err == ErrPercentageMismatch
+}
+
+func validateRecurrence(r *model.RecurringExpense) error {
+	switch r.Frequency {
+	case model.FrequencyDaily, model.FrequencyWeekly, model.FrequencyMonthly, model.FrequencyYearly:
+	default:
+		return ErrInvalidRecurrence
+	}
+	if r.Interval < 1 || r.DayOfMonth < 0 || r.DayOfMonth > 31 || r.StartDate.IsZero() {
+		return ErrInvalidRecurrence
+	}
+	if r.EndDate != nil && r.EndDate.Before(r.StartDate) {
+		return ErrInvalidRecurrence
+	}
+	return nil
+}
+
+// firstOccurrence is the start date, moved onto the pinned day of month when one is set.
+func firstOccurrence(r *model.RecurringExpense) time.Time {
+	if r.Frequency != model.FrequencyMonthly && r.Frequency != model.FrequencyYearly {
+		return r.StartDate
+	}
+	first := onDayOfMonth(r.StartDate, r.DayOfMonth)
+	if first.Before(r.StartDate) {
+		first = nextOccurrence(r, first)
+	}
+	return first
+}
+
+// nextOccurrence returns the occurrence following `from`. Monthly and yearly recurrences are
+// computed from the pinned day of month, so a 31st that was clamped to the 28th in February
+// goes back to the 31st in March instead of drifting.
+func nextOccurrence(r *model.RecurringExpense, from time.Time) time.Time {
+	switch r.Frequency {
+	case model.FrequencyDaily:
+		return from.AddDate(0, 0, r.Interval)
+	case model.FrequencyWeekly:
+		return from.AddDate(0, 0, 7*r.Interval)
+	case model.FrequencyYearly:
+		return onDayOfMonth(addMonths(from, 12*r.Interval), r.DayOfMonth)
+	default:
+		return onDayOfMonth(addMonths(from, r.Interval), r.DayOfMonth)
+	}
+}
+
+// addMonths moves t to the first day of the month `months` later, keeping the time of day.
+func addMonths(t time.Time, months int) time.Time {
+	return time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// onDayOfMonth moves t to the given day of its month, clamped to the month's last day.
+func onDayOfMonth(t time.Time, day int) time.Time {
+	lastDay := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(t.Year(), t.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func recurringSplitInputs(splits []model.RecurringExpenseSplit) []SplitInput {
+	inputs := make([]SplitInput, len(splits))
+	for i, s := range splits {
+		inputs[i] = SplitInput{
+			UserID:     s.UserID,
+			Amount:     s.Amount,
+			Percentage: s.Percentage,
+			Shares:     s.Shares,
+		}
+	}
+	return inputs
+}
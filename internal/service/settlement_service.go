@@ -2,70 +2,336 @@ package service
 
 import (
 	"context"
+	"sort"
 
 	"expense-tracker/internal/algorithm"
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/currency"
 	"expense-tracker/internal/model"
 	"expense-tracker/internal/repository"
 )
 
 type SettlementService interface {
 	CalculateBalances(ctx context.Context, groupID uint) ([]model.UserBalance, error)
-	GetSettlements(ctx context.Context, groupID uint) ([]model.Settlement, error)
+	GetSettlements(ctx context.Context, groupID uint, strategy algorithm.Strategy) ([]model.Settlement, error)
+	GetUserSettlements(ctx context.Context, userID uint, strategy algorithm.Strategy) (*model.UserSettlementSummary, error)
+	// ExplainSettlements returns the suggested settlements with a step-by-step audit trail.
+	ExplainSettlements(ctx context.Context, groupID uint, strategy algorithm.Strategy) (*model.SettlementExplanation, error)
+	// GetDebts returns who owes whom, either raw from the expense splits or simplified by the settlement algorithm.
+	GetDebts(ctx context.Context, groupID uint, simplified bool, strategy algorithm.Strategy) ([]model.Debt, error)
 }
 
 type settlementService struct {
 	expenseRepo repository.ExpenseRepository
+	paymentRepo repository.PaymentRepository
+	groupRepo   repository.GroupRepository
 }
 
-func NewSettlementService(expenseRepo repository.ExpenseRepository) SettlementService {
-	return &settlementService{expenseRepo: expenseRepo}
+func NewSettlementService(expenseRepo repository.ExpenseRepository, paymentRepo repository.PaymentRepository, groupRepo repository.GroupRepository) SettlementService {
+	return &settlementService{expenseRepo: expenseRepo, paymentRepo: paymentRepo, groupRepo: groupRepo}
 }
 
 func (s *settlementService) CalculateBalances(ctx context.Context, groupID uint) ([]model.UserBalance, error) {
-	expenses, err := s.expenseRepo.GetExpensesByGroupID(ctx, groupID)
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+	return s.calculateBalances(ctx, groupID)
+}
+
+func (s *settlementService) calculateBalances(ctx context.Context, groupID uint) ([]model.UserBalance, error) {
+	ledger, err := s.loadLedger(ctx, groupID)
 	if err != nil {
 		return nil, err
 	}
+	return ledger.balances(), nil
+}
 
-	splits, err := s.expenseRepo.GetExpenseSplitsByGroupID(ctx, groupID)
+// loadLedger reads everything that affects a group's balances, with all expenses already
+// converted into the group's base currency.
+func (s *settlementService) loadLedger(ctx context.Context, groupID uint) (*groupLedger, error) {
+	group, err := getGroup(ctx, s.groupRepo, groupID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate net balances
-	// positive balance = person is owed money
-	// negative balance = person owes money
-	balancesMap := make(map[uint]int64)
+	expenses, err := loadConvertedExpenses(ctx, s.expenseRepo, group)
+	if err != nil {
+		return nil, err
+	}
 
-	// Add what each person paid (they are owed this money)
-	for _, exp := range expenses {
-		balancesMap[exp.PayerID] += exp.Amount
+	payments, err := s.paymentRepo.GetPaymentsByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Subtract what each person owes (their share of the expense)
-	for _, split := range splits {
-		balancesMap[split.UserID] -= split.Amount
+	return &groupLedger{group: group, expenses: expenses, payments: payments}, nil
+}
+
+func (s *settlementService) GetSettlements(ctx context.Context, groupID uint, strategy algorithm.Strategy) ([]model.Settlement, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+
+	balances, err := s.calculateBalances(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use algorithm to minimize transactions
+	return algorithm.Settle(balances, strategy), nil
+}
+
+func (s *settlementService) ExplainSettlements(ctx context.Context, groupID uint, strategy algorithm.Strategy) (*model.SettlementExplanation, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+
+	ledger, err := s.loadLedger(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := ledger.balances()
+	settlements := algorithm.Settle(balances, strategy)
+	steps := algorithm.TraceSettlements(balances, settlements)
+	for i := range steps {
+		steps[i].Contributions = ledger.contributions(steps[i].Settlement.FromUserID, steps[i].Settlement.ToUserID)
+	}
+
+	if settlements == nil {
+		settlements = []model.Settlement{}
+	}
+	return &model.SettlementExplanation{
+		Strategy:    string(strategy),
+		Settlements: settlements,
+		Steps:       steps,
+	}, nil
+}
+
+func (s *settlementService) GetDebts(ctx context.Context, groupID uint, simplified bool, strategy algorithm.Strategy) ([]model.Debt, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+
+	ledger, err := s.loadLedger(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !simplified {
+		return ledger.pairwiseDebts(), nil
+	}
+
+	var debts []model.Debt
+	for _, st := range algorithm.Settle(ledger.balances(), strategy) {
+		debts = append(debts, model.Debt{FromUserID: st.FromUserID, ToUserID: st.ToUserID, Amount: st.Amount})
+	}
+	sortDebts(debts)
+	return debts, nil
+}
+
+// GetUserSettlements simplifies a user's debts across all of their groups. What the user and each
+// other member owe each other directly in every group is netted per counterparty, so owing someone
+// in one group and being owed by them in another collapses into a single transfer. Debts come from
+// the pairwise ledger rather than each group's suggested settlements, which may route money through
+// people the user never shared an expense with. Groups with different base currencies are never
+// mixed; each currency is settled separately.
+func (s *settlementService) GetUserSettlements(ctx context.Context, userID uint, strategy algorithm.Strategy) (*model.UserSettlementSummary, error) {
+	callerID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if callerID != userID {
+		return nil, ErrForbidden
+	}
+
+	groups, err := s.groupRepo.GetGroupsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type counterpartyKey struct {
+		userID   uint
+		currency string
 	}
+	counterparties := make(map[counterpartyKey]*model.CounterpartyBalance)
 
-	var userBalances []model.UserBalance
-	for userID, balance := range balancesMap {
-		if balance != 0 {
-			userBalances = append(userBalances, model.UserBalance{
-				UserID:  userID,
-				Balance: balance,
+	for _, group := range groups {
+		ledger, err := s.loadLedger(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		baseCurrency := currency.Normalize(group.BaseCurrency)
+		for _, debt := range ledger.pairwiseDebts() {
+			var otherID uint
+			var amount int64
+			switch userID {
+			case debt.ToUserID:
+				otherID, amount = debt.FromUserID, debt.Amount
+			case debt.FromUserID:
+				otherID, amount = debt.ToUserID, -debt.Amount
+			default:
+				continue
+			}
+
+			key := counterpartyKey{userID: otherID, currency: baseCurrency}
+			cp, ok := counterparties[key]
+			if !ok {
+				cp = &model.CounterpartyBalance{UserID: otherID, Currency: baseCurrency}
+				counterparties[key] = cp
+			}
+			cp.Balance += amount
+			cp.Groups = append(cp.Groups, model.GroupContribution{
+				GroupID:    group.ID,
+				GroupTitle: group.Title,
+				Amount:     amount,
 			})
 		}
 	}
 
-	return userBalances, nil
+	summary := &model.UserSettlementSummary{
+		UserID:         userID,
+		Settlements:    []model.Settlement{},
+		Counterparties: []model.CounterpartyBalance{},
+	}
+
+	// Run the settlement algorithm on the combined pairwise balances, once per currency
+	combined := make(map[string][]model.UserBalance)
+	for key, cp := range counterparties {
+		summary.Counterparties = append(summary.Counterparties, *cp)
+		if cp.Balance == 0 {
+			continue
+		}
+		combined[key.currency] = append(combined[key.currency],
+			model.UserBalance{UserID: userID, Balance: -cp.Balance},
+			model.UserBalance{UserID: cp.UserID, Balance: cp.Balance},
+		)
+	}
+	for code, balances := range combined {
+		for _, st := range algorithm.Settle(mergeBalances(balances), strategy) {
+			st.Currency = code
+			summary.Settlements = append(summary.Settlements, st)
+		}
+	}
+
+	sort.Slice(summary.Counterparties, func(i, j int) bool {
+		a, b := summary.Counterparties[i], summary.Counterparties[j]
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.UserID < b.UserID
+	})
+	sort.Slice(summary.Settlements, func(i, j int) bool {
+		a, b := summary.Settlements[i], summary.Settlements[j]
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		if a.FromUserID != b.FromUserID {
+			return a.FromUserID < b.FromUserID
+		}
+		return a.ToUserID < b.ToUserID
+	})
+
+	return summary, nil
 }
 
-func (s *settlementService) GetSettlements(ctx context.Context, groupID uint) ([]model.Settlement, error) {
-	balances, err := s.CalculateBalances(ctx, groupID)
+// loadConvertedExpenses reads a group's expenses with their payers and splits attached,
+// converted into the group's base currency.
+func loadConvertedExpenses(ctx context.Context, expenseRepo repository.ExpenseRepository, group *model.Group) ([]model.Expense, error) {
+	expenses, err := expenseRepo.GetExpensesByGroupID(ctx, group.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Use algorithm to minimize transactions
-	return algorithm.CalculateSettlements(balances), nil
+	splits, err := expenseRepo.GetExpenseSplitsByGroupID(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	payers, err := expenseRepo.GetExpensePayersByGroupID(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	splitsByExpense := make(map[uint][]model.ExpenseSplit)
+	for _, split := range splits {
+		splitsByExpense[split.ExpenseID] = append(splitsByExpense[split.ExpenseID], split)
+	}
+	payersByExpense := make(map[uint][]model.ExpensePayer)
+	for _, payer := range payers {
+		payersByExpense[payer.ExpenseID] = append(payersByExpense[payer.ExpenseID], payer)
+	}
+
+	for i := range expenses {
+		expenses[i].Splits = splitsByExpense[expenses[i].ID]
+		expenses[i].Payers = payersByExpense[expenses[i].ID]
+		expenses[i] = toBaseCurrency(expenses[i], group.BaseCurrency)
+	}
+	return expenses, nil
+}
+
+// mergeBalances adds up balances that belong to the same user.
+func mergeBalances(balances []model.UserBalance) []model.UserBalance {
+	totals := make(map[uint]int64)
+	for _, b := range balances {
+		totals[b.UserID] += b.Balance
+	}
+
+	merged := make([]model.UserBalance, 0, len(totals))
+	for id, balance := range totals {
+		merged = append(merged, model.UserBalance{UserID: id, Balance: balance})
+	}
+	return merged
+}
+
+// toBaseCurrency converts an expense with its payers and splits into the group's base currency
+// using the rate stored on the expense. The converted total is rounded once and then
+// distributed over the payers and the splits proportionally, so both still add up to the
+// converted total.
+func toBaseCurrency(exp model.Expense, baseCurrency string) model.Expense {
+	expCurrency := currency.Normalize(exp.Currency)
+	baseCurrency = currency.Normalize(baseCurrency)
+	if expCurrency == baseCurrency {
+		return exp
+	}
+
+	amount := currency.Convert(exp.Amount, expCurrency, baseCurrency, exp.ExchangeRate)
+
+	if len(exp.Splits) > 0 {
+		weights := make([]int64, len(exp.Splits))
+		ids := make([]uint, len(exp.Splits))
+		for i, split := range exp.Splits {
+			weights[i] = split.Amount
+			ids[i] = split.UserID
+		}
+		converted := allocateByWeights(amount, weights, ids)
+
+		splits := make([]model.ExpenseSplit, len(exp.Splits))
+		for i, split := range exp.Splits {
+			split.Amount = converted[i]
+			splits[i] = split
+		}
+		exp.Splits = splits
+	}
+
+	if len(exp.Payers) > 0 {
+		weights := make([]int64, len(exp.Payers))
+		ids := make([]uint, len(exp.Payers))
+		for i, payer := range exp.Payers {
+			weights[i] = payer.Amount
+			ids[i] = payer.UserID
+		}
+		converted := allocateByWeights(amount, weights, ids)
+
+		payers := make([]model.ExpensePayer, len(exp.Payers))
+		for i, payer := range exp.Payers {
+			payer.Amount = converted[i]
+			payers[i] = payer
+		}
+		exp.Payers = payers
+	}
+
+	exp.Amount = amount
+	return exp
 }
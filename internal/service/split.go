@@ -0,0 +1,303 @@
+package service
+
+import (
+	"errors"
+	"math/bits"
+	"sort"
+
+	"expense-tracker/internal/model"
+)
+
+var (
+	ErrInvalidSplitType   = errors.New("unsupported split type")
+	ErrInvalidSplit       = errors.New("invalid split: each user may appear once with a positive value")
+	ErrPercentageMismatch = errors.New("split percentages must add up to 100")
+	ErrInvalidItem        = errors.New("invalid item: each item needs a positive amount and quantity and at least one user, and tax and tip can't be negative")
+	ErrInvalidPayers      = errors.New("invalid payers: each user may appear once with a positive amount, and payer_id must be one of them")
+	ErrPayerMismatch      = errors.New("the sum of payer amounts does not equal the total amount")
+)
+
+// percentageScale is the number of units that make up 100%. Percentages are carried
+// in basis points (hundredths of a percent) so that they stay integer like the amounts.
+const percentageScale = 10000
+
+// maxShares caps a single participant's shares, which keeps the sum of everyone's shares
+// far from overflowing.
+const maxShares = 1_000_000
+
+// SplitInput describes one participant's part of an expense. Which field is read
+// depends on the expense's split type:
+//   - exact:      Amount in cents
+//   - percentage: Percentage in basis points (2500 = 25%)
+//   - shares:     Shares as a relative weight
+//   - equal:      only UserID is used
+type SplitInput struct {
+	UserID     uint
+	Amount     int64
+	Percentage int64
+	Shares     int64
+}
+
+// computeSplits turns the requested split into concrete per-user cent amounts that always
+// add up to amount. Leftover cents from integer division are handed out deterministically.
+func computeSplits(amount int64, splitType model.SplitType, inputs []SplitInput) ([]model.ExpenseSplit, error) {
+	if len(inputs) == 0 {
+		return nil, ErrInvalidSplit
+	}
+
+	seen := make(map[uint]bool, len(inputs))
+	for _, in := range inputs {
+		if in.UserID == 0 || seen[in.UserID] {
+			return nil, ErrInvalidSplit
+		}
+		seen[in.UserID] = true
+	}
+
+	weights := make([]int64, len(inputs))
+	switch splitType {
+	case model.SplitTypeExact, "":
+		// Count down from the amount rather than adding up, so that no sum can overflow
+		splits := make([]model.ExpenseSplit, len(inputs))
+		remaining := amount
+		for i, in := range inputs {
+			if in.Amount <= 0 {
+				return nil, ErrInvalidSplit
+			}
+			if in.Amount > remaining {
+				return nil, ErrSplitMismatch
+			}
+			remaining -= in.Amount
+			splits[i] = model.ExpenseSplit{UserID: in.UserID, Amount: in.Amount}
+		}
+		if remaining != 0 {
+			return nil, ErrSplitMismatch
+		}
+		return splits, nil
+	case model.SplitTypeEqual:
+		for i := range inputs {
+			weights[i] = 1
+		}
+	case model.SplitTypePercentage:
+		var total int64
+		for i, in := range inputs {
+			if in.Percentage <= 0 {
+				return nil, ErrInvalidSplit
+			}
+			if in.Percentage > percentageScale {
+				return nil, ErrPercentageMismatch
+			}
+			weights[i] = in.Percentage
+			total += in.Percentage
+		}
+		if total != percentageScale {
+			return nil, ErrPercentageMismatch
+		}
+	case model.SplitTypeShares:
+		for i, in := range inputs {
+			if in.Shares <= 0 || in.Shares > maxShares {
+				return nil, ErrInvalidSplit
+			}
+			weights[i] = in.Shares
+		}
+	default:
+		return nil, ErrInvalidSplitType
+	}
+
+	amounts := allocateByWeights(amount, weights, userIDs(inputs))
+	splits := make([]model.ExpenseSplit, len(inputs))
+	for i, in := range inputs {
+		splits[i] = model.ExpenseSplit{UserID: in.UserID, Amount: amounts[i]}
+	}
+	return splits, nil
+}
+
+// allocateByWeights divides amount proportionally to weights using the largest-remainder
+// method: everyone first gets the floor of their exact share, then the cents left over go
+// one each to the largest fractional remainders. Ties are broken by the lower tie-break key
+// (user ID), so the same input always produces the same split.
+//
+// amount must not be negative and the weights must be positive with a sum that fits in an
+// int64. Each amount * weight product is worked out in 128 bits, so it can't overflow.
+func allocateByWeights(amount int64, weights []int64, tieBreak []uint) []int64 {
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+
+	result := make([]int64, len(weights))
+	remainders := make([]int64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		// w <= total keeps the quotient at most amount, as Div64 requires
+		hi, lo := bits.Mul64(uint64(amount), uint64(w))
+		quo, rem := bits.Div64(hi, lo, uint64(total))
+		result[i] = int64(quo)
+		remainders[i] = int64(rem)
+		allocated += result[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := order[a], order[b]
+		if remainders[ia] != remainders[ib] {
+			return remainders[ia] > remainders[ib]
+		}
+		return tieBreak[ia] < tieBreak[ib]
+	})
+
+	for k := int64(0); k < amount-allocated; k++ {
+		result[order[k]]++
+	}
+
+	return result
+}
+
+// ItemInput is one line of an itemized receipt: UnitAmount cents times Quantity, shared
+// equally by UserIDs.
+type ItemInput struct {
+	Name       string
+	UnitAmount int64
+	Quantity   int64
+	UserIDs    []uint
+}
+
+// computeItemizedSplits derives per-user splits from line items. Each item's total is divided
+// equally between its users; tax and tip are then spread in proportion to what each user's
+// items came to. The items plus tax and tip must add up to amount.
+func computeItemizedSplits(amount int64, items []ItemInput, tax, tip int64) ([]model.ExpenseSplit, error) {
+	if len(items) == 0 || tax < 0 || tip < 0 {
+		return nil, ErrInvalidItem
+	}
+
+	// Count down from the amount rather than adding up, so that no sum can overflow
+	if tax > amount || tip > amount-tax {
+		return nil, ErrSplitMismatch
+	}
+	remaining := amount - tax - tip
+	subtotals := make(map[uint]int64)
+	for _, item := range items {
+		if item.UnitAmount <= 0 || item.Quantity <= 0 || len(item.UserIDs) == 0 {
+			return nil, ErrInvalidItem
+		}
+
+		seen := make(map[uint]bool, len(item.UserIDs))
+		weights := make([]int64, len(item.UserIDs))
+		for i, id := range item.UserIDs {
+			if id == 0 || seen[id] {
+				return nil, ErrInvalidItem
+			}
+			seen[id] = true
+			weights[i] = 1
+		}
+
+		if item.Quantity > remaining/item.UnitAmount {
+			return nil, ErrSplitMismatch
+		}
+		itemTotal := item.UnitAmount * item.Quantity
+		remaining -= itemTotal
+		for i, share := range allocateByWeights(itemTotal, weights, item.UserIDs) {
+			subtotals[item.UserIDs[i]] += share
+		}
+	}
+	if remaining != 0 {
+		return nil, ErrSplitMismatch
+	}
+
+	ids := make([]uint, 0, len(subtotals))
+	for id := range subtotals {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	weights := make([]int64, len(ids))
+	for i, id := range ids {
+		weights[i] = subtotals[id]
+	}
+	extras := allocateByWeights(tax+tip, weights, ids)
+
+	splits := make([]model.ExpenseSplit, len(ids))
+	for i, id := range ids {
+		splits[i] = model.ExpenseSplit{UserID: id, Amount: subtotals[id] + extras[i]}
+	}
+	return splits, nil
+}
+
+// PayerInput is one person's contribution, in cents, towards paying an expense.
+type PayerInput struct {
+	UserID uint
+	Amount int64
+}
+
+// computePayers resolves who paid an expense. Without inputs payerID paid the whole amount,
+// which keeps single-payer requests working unchanged. Otherwise the contributions must add up
+// to amount, and the returned primary payer is payerID if given, or else whoever paid the most.
+func computePayers(amount int64, payerID uint, inputs []PayerInput) ([]model.ExpensePayer, uint, error) {
+	if len(inputs) == 0 {
+		if payerID == 0 {
+			return nil, 0, ErrInvalidPayers
+		}
+		return []model.ExpensePayer{{UserID: payerID, Amount: amount}}, payerID, nil
+	}
+
+	payers := make([]model.ExpensePayer, len(inputs))
+	seen := make(map[uint]bool, len(inputs))
+	// Count down from the amount, as for exact splits, so that no sum can overflow
+	remaining := amount
+	for i, in := range inputs {
+		if in.UserID == 0 || in.Amount <= 0 || seen[in.UserID] {
+			return nil, 0, ErrInvalidPayers
+		}
+		if in.Amount > remaining {
+			return nil, 0, ErrPayerMismatch
+		}
+		seen[in.UserID] = true
+		remaining -= in.Amount
+		payers[i] = model.ExpensePayer{UserID: in.UserID, Amount: in.Amount}
+	}
+	if remaining != 0 {
+		return nil, 0, ErrPayerMismatch
+	}
+	if payerID != 0 && !seen[payerID] {
+		return nil, 0, ErrInvalidPayers
+	}
+
+	sort.Slice(payers, func(i, j int) bool { return payers[i].UserID < payers[j].UserID })
+	if payerID == 0 {
+		primary := payers[0]
+		for _, p := range payers[1:] {
+			if p.Amount > primary.Amount {
+				primary = p
+			}
+		}
+		payerID = primary.UserID
+	}
+	return payers, payerID, nil
+}
+
+// itemUserIDs lists every user assigned to at least one item.
+func itemUserIDs(items []ItemInput) []uint {
+	var ids []uint
+	for _, item := range items {
+		ids = append(ids, item.UserIDs...)
+	}
+	return ids
+}
+
+func payerUserIDs(payers []model.ExpensePayer) []uint {
+	ids := make([]uint, len(payers))
+	for i, p := range payers {
+		ids[i] = p.UserID
+	}
+	return ids
+}
+
+func userIDs(inputs []SplitInput) []uint {
+	ids := make([]uint, len(inputs))
+	for i, in := range inputs {
+		ids[i] = in.UserID
+	}
+	return ids
+}
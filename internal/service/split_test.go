@@ -0,0 +1,279 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"expense-tracker/internal/model"
+)
+
+func TestAllocateByWeights(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		amount   int64
+		weights  []int64
+		tieBreak []uint
+		want     []int64
+	}{
+		{"even", 90, []int64{1, 1, 1}, []uint{1, 2, 3}, []int64{30, 30, 30}},
+		{"tie goes to the lowest user ID", 100, []int64{1, 1, 1}, []uint{3, 1, 2}, []int64{33, 34, 33}},
+		{"ties in input order do not matter", 2, []int64{1, 1, 1}, []uint{5, 2, 9}, []int64{1, 1, 0}},
+		{"largest remainder beats a lower user ID", 10, []int64{1, 2}, []uint{1, 2}, []int64{3, 7}},
+		{"zero amount", 0, []int64{3, 7}, []uint{1, 2}, []int64{0, 0}},
+		{"products past 64 bits", math.MaxInt64, []int64{1, 1}, []uint{1, 2}, []int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}},
+	} {
+		got := allocateByWeights(tc.amount, tc.weights, tc.tieBreak)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: allocateByWeights(%d, %v) = %v, want %v", tc.name, tc.amount, tc.weights, got, tc.want)
+		}
+	}
+}
+
+func TestComputeSplits(t *testing.T) {
+	users := func(ids ...uint) []SplitInput {
+		inputs := make([]SplitInput, len(ids))
+		for i, id := range ids {
+			inputs[i] = SplitInput{UserID: id}
+		}
+		return inputs
+	}
+
+	for _, tc := range []struct {
+		name      string
+		amount    int64
+		splitType model.SplitType
+		inputs    []SplitInput
+		want      []int64
+		wantErr   error
+	}{
+		{"equal", 100, model.SplitTypeEqual, users(1, 2, 3), []int64{34, 33, 33}, nil},
+		{"equal between one", 100, model.SplitTypeEqual, users(7), []int64{100}, nil},
+		{
+			"percentage remainder goes to the largest fraction", 100, model.SplitTypePercentage,
+			[]SplitInput{{UserID: 1, Percentage: 3333}, {UserID: 2, Percentage: 3333}, {UserID: 3, Percentage: 3334}},
+			[]int64{33, 33, 34}, nil,
+		},
+		{
+			"percentage of everything", 999, model.SplitTypePercentage,
+			[]SplitInput{{UserID: 4, Percentage: percentageScale}},
+			[]int64{999}, nil,
+		},
+		{
+			"percentages short of 100", 100, model.SplitTypePercentage,
+			[]SplitInput{{UserID: 1, Percentage: 5000}, {UserID: 2, Percentage: 4999}},
+			nil, ErrPercentageMismatch,
+		},
+		{
+			"percentage over 100", 100, model.SplitTypePercentage,
+			[]SplitInput{{UserID: 1, Percentage: percentageScale + 1}, {UserID: 2, Percentage: 1}},
+			nil, ErrPercentageMismatch,
+		},
+		{
+			"zero percentage", 100, model.SplitTypePercentage,
+			[]SplitInput{{UserID: 1, Percentage: percentageScale}, {UserID: 2}},
+			nil, ErrInvalidSplit,
+		},
+		{
+			"shares", 100, model.SplitTypeShares,
+			[]SplitInput{{UserID: 1, Shares: 1}, {UserID: 2, Shares: 2}},
+			[]int64{33, 67}, nil,
+		},
+		{
+			"shares at the cap", math.MaxInt64, model.SplitTypeShares,
+			[]SplitInput{{UserID: 1, Shares: maxShares}, {UserID: 2, Shares: maxShares}},
+			[]int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}, nil,
+		},
+		{
+			"shares over the cap", 100, model.SplitTypeShares,
+			[]SplitInput{{UserID: 1, Shares: maxShares + 1}, {UserID: 2, Shares: 1}},
+			nil, ErrInvalidSplit,
+		},
+		{
+			"exact", 100, model.SplitTypeExact,
+			[]SplitInput{{UserID: 1, Amount: 60}, {UserID: 2, Amount: 40}},
+			[]int64{60, 40}, nil,
+		},
+		{
+			"exact short of the amount", 100, model.SplitTypeExact,
+			[]SplitInput{{UserID: 1, Amount: 60}, {UserID: 2, Amount: 39}},
+			nil, ErrSplitMismatch,
+		},
+		{
+			// Summed in int64 these wrap around to exactly the amount
+			"exact amounts that overflow", 100, model.SplitTypeExact,
+			[]SplitInput{{UserID: 1, Amount: math.MaxInt64}, {UserID: 2, Amount: math.MaxInt64}, {UserID: 3, Amount: 102}},
+			nil, ErrSplitMismatch,
+		},
+		{
+			"zero exact amount", 100, model.SplitTypeExact,
+			[]SplitInput{{UserID: 1, Amount: 100}, {UserID: 2}},
+			nil, ErrInvalidSplit,
+		},
+		{"no participants", 100, model.SplitTypeEqual, nil, nil, ErrInvalidSplit},
+		{"duplicate participant", 100, model.SplitTypeEqual, users(1, 2, 1), nil, ErrInvalidSplit},
+		{"missing user", 100, model.SplitTypeEqual, users(1, 0), nil, ErrInvalidSplit},
+		{"unknown type", 100, "halves", users(1, 2), nil, ErrInvalidSplitType},
+	} {
+		splits, err := computeSplits(tc.amount, tc.splitType, tc.inputs)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		got := make([]int64, len(splits))
+		for i, split := range splits {
+			if split.UserID != tc.inputs[i].UserID {
+				t.Errorf("%s: split %d is for user %d, want %d", tc.name, i, split.UserID, tc.inputs[i].UserID)
+			}
+			got[i] = split.Amount
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestComputePayers(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		amount      int64
+		payerID     uint
+		inputs      []PayerInput
+		want        []model.ExpensePayer
+		wantPrimary uint
+		wantErr     error
+	}{
+		{"single payer", 100, 3, nil, []model.ExpensePayer{{UserID: 3, Amount: 100}}, 3, nil},
+		{"no payer", 100, 0, nil, nil, 0, ErrInvalidPayers},
+		{
+			"largest contribution is the primary payer", 100, 0,
+			[]PayerInput{{UserID: 5, Amount: 30}, {UserID: 2, Amount: 70}},
+			[]model.ExpensePayer{{UserID: 2, Amount: 70}, {UserID: 5, Amount: 30}}, 2, nil,
+		},
+		{
+			"equal contributions go to the lowest user ID", 100, 0,
+			[]PayerInput{{UserID: 5, Amount: 50}, {UserID: 2, Amount: 50}},
+			[]model.ExpensePayer{{UserID: 2, Amount: 50}, {UserID: 5, Amount: 50}}, 2, nil,
+		},
+		{
+			"named primary payer", 100, 5,
+			[]PayerInput{{UserID: 5, Amount: 30}, {UserID: 2, Amount: 70}},
+			[]model.ExpensePayer{{UserID: 2, Amount: 70}, {UserID: 5, Amount: 30}}, 5, nil,
+		},
+		{
+			"primary payer who did not pay", 100, 9,
+			[]PayerInput{{UserID: 5, Amount: 30}, {UserID: 2, Amount: 70}},
+			nil, 0, ErrInvalidPayers,
+		},
+		{
+			"contributions short of the amount", 100, 0,
+			[]PayerInput{{UserID: 5, Amount: 30}, {UserID: 2, Amount: 69}},
+			nil, 0, ErrPayerMismatch,
+		},
+		{
+			// Summed in int64 these wrap around to exactly the amount
+			"contributions that overflow", 100, 0,
+			[]PayerInput{{UserID: 1, Amount: math.MaxInt64}, {UserID: 2, Amount: math.MaxInt64}, {UserID: 3, Amount: 102}},
+			nil, 0, ErrPayerMismatch,
+		},
+		{
+			"duplicate payer", 100, 0,
+			[]PayerInput{{UserID: 2, Amount: 50}, {UserID: 2, Amount: 50}},
+			nil, 0, ErrInvalidPayers,
+		},
+		{
+			"zero contribution", 100, 0,
+			[]PayerInput{{UserID: 2, Amount: 100}, {UserID: 3}},
+			nil, 0, ErrInvalidPayers,
+		},
+	} {
+		payers, primary, err := computePayers(tc.amount, tc.payerID, tc.inputs)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if !slices.Equal(payers, tc.want) || primary != tc.wantPrimary {
+			t.Errorf("%s: got %v paid by %d, want %v paid by %d", tc.name, payers, primary, tc.want, tc.wantPrimary)
+		}
+	}
+}
+
+func TestComputeItemizedSplits(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		amount   int64
+		items    []ItemInput
+		tax, tip int64
+		want     []model.ExpenseSplit
+		wantErr  error
+	}{
+		{
+			"tax follows each user's subtotal", 1760,
+			[]ItemInput{{UnitAmount: 1000, Quantity: 1, UserIDs: []uint{1}}, {UnitAmount: 300, Quantity: 2, UserIDs: []uint{2, 1}}},
+			100, 60,
+			[]model.ExpenseSplit{{UserID: 1, Amount: 1430}, {UserID: 2, Amount: 330}}, nil,
+		},
+		{
+			"leftover item cent goes to the lowest user ID", 100,
+			[]ItemInput{{UnitAmount: 100, Quantity: 1, UserIDs: []uint{3, 1, 2}}},
+			0, 0,
+			[]model.ExpenseSplit{{UserID: 1, Amount: 34}, {UserID: 2, Amount: 33}, {UserID: 3, Amount: 33}}, nil,
+		},
+		{
+			"leftover tax cent goes to the lowest user ID", 101,
+			[]ItemInput{{UnitAmount: 50, Quantity: 1, UserIDs: []uint{2}}, {UnitAmount: 50, Quantity: 1, UserIDs: []uint{1}}},
+			1, 0,
+			[]model.ExpenseSplit{{UserID: 1, Amount: 51}, {UserID: 2, Amount: 50}}, nil,
+		},
+		{
+			"items short of the amount", 1000,
+			[]ItemInput{{UnitAmount: 300, Quantity: 3, UserIDs: []uint{1}}},
+			50, 0, nil, ErrSplitMismatch,
+		},
+		{
+			"tax over the amount", 100,
+			[]ItemInput{{UnitAmount: 1, Quantity: 1, UserIDs: []uint{1}}},
+			math.MaxInt64, 0, nil, ErrSplitMismatch,
+		},
+		{
+			"tip past what tax leaves", 100,
+			[]ItemInput{{UnitAmount: 1, Quantity: 1, UserIDs: []uint{1}}},
+			50, math.MaxInt64, nil, ErrSplitMismatch,
+		},
+		{
+			"item total that overflows", 100,
+			[]ItemInput{{UnitAmount: 2, Quantity: math.MaxInt64/2 + 1, UserIDs: []uint{1}}},
+			0, 0, nil, ErrSplitMismatch,
+		},
+		{
+			"negative tip", 100,
+			[]ItemInput{{UnitAmount: 100, Quantity: 1, UserIDs: []uint{1}}},
+			0, -1, nil, ErrInvalidItem,
+		},
+		{
+			"item without users", 100,
+			[]ItemInput{{UnitAmount: 100, Quantity: 1}},
+			0, 0, nil, ErrInvalidItem,
+		},
+		{
+			"user twice on one item", 100,
+			[]ItemInput{{UnitAmount: 100, Quantity: 1, UserIDs: []uint{1, 1}}},
+			0, 0, nil, ErrInvalidItem,
+		},
+		{"no items", 100, nil, 0, 0, nil, ErrInvalidItem},
+	} {
+		splits, err := computeItemizedSplits(tc.amount, tc.items, tc.tax, tc.tip)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if !slices.Equal(splits, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, splits, tc.want)
+		}
+	}
+}
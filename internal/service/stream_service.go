@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"expense-tracker/internal/auth"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/stream"
+)
+
+// Event types sent on a group's stream.
+const (
+	StreamExpense  = "expense"  // An expense was added, edited or deleted; data is the activity
+	StreamPayment  = "payment"  // A payment was recorded; data is the activity
+	StreamBalances = "balances" // The group's balances after a change; data is the balance list
+	StreamReset    = "reset"    // Events were missed that can't be replayed; reload the group
+)
+
+// StreamToken lets a client that can't send an Authorization header open a group's stream,
+// by passing it in the token query parameter. It expires after auth.StreamTokenTTL.
+type StreamToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type StreamService interface {
+	ActivityListener
+	// IssueToken returns a stream token for the caller and the group. The token is only checked
+	// when the stream is opened, so a client fetches a new one each time it reconnects.
+	IssueToken(ctx context.Context, groupID uint) (*StreamToken, error)
+	// Subscribe opens the caller's stream of the group. The returned events go out before
+	// anything from the subscription: the ones missed since lastEventID, or a reset when they
+	// can't all be replayed, followed by the current balances.
+	Subscribe(ctx context.Context, groupID uint, lastEventID string) (*stream.Subscription, []stream.Event, error)
+	Unsubscribe(sub *stream.Subscription)
+}
+
+type streamService struct {
+	hub         *stream.Hub
+	groupRepo   repository.GroupRepository
+	settlements SettlementService
+	tokens      *auth.TokenManager
+	logger      *slog.Logger
+}
+
+func NewStreamService(hub *stream.Hub, groupRepo repository.GroupRepository, settlements SettlementService, tokens *auth.TokenManager, logger *slog.Logger) StreamService {
+	return &streamService{hub: hub, groupRepo: groupRepo, settlements: settlements, tokens: tokens, logger: logger}
+}
+
+func (s *streamService) IssueToken(ctx context.Context, groupID uint) (*StreamToken, error) {
+	userID, err := requireMember(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, err
+	}
+	token, expiresAt, err := s.tokens.IssueStreamToken(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamToken{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+func (s *streamService) Subscribe(ctx context.Context, groupID uint, lastEventID string) (*stream.Subscription, []stream.Event, error) {
+	userID, err := requireMember(ctx, s.groupRepo, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Subscribe before loading the balances, so that a change in between is sent rather than lost
+	sub, missed, complete, err := s.hub.Subscribe(groupID, userID, lastEventID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balances, err := s.settlements.CalculateBalances(ctx, groupID)
+	if err != nil {
+		s.hub.Unsubscribe(sub)
+		return nil, nil, err
+	}
+	snapshot, err := json.Marshal(balances)
+	if err != nil {
+		s.hub.Unsubscribe(sub)
+		return nil, nil, err
+	}
+
+	initial := missed
+	if !complete {
+		initial = append(initial, stream.Event{Type: StreamReset, Data: json.RawMessage("{}")})
+	}
+	initial = append(initial, stream.Event{Type: StreamBalances, Data: snapshot})
+
+	return sub, initial, nil
+}
+
+func (s *streamService) Unsubscribe(sub *stream.Subscription) {
+	s.hub.Unsubscribe(sub)
+}
+
+// OnActivity publishes expense and payment changes followed by the balances they lead to, and
+// cuts off the streams of members who leave. Balances are only worked out while someone is
+// listening. Streaming is best-effort, so failures are logged rather than returned.
+func (s *streamService) OnActivity(ctx context.Context, activity *model.Activity) error {
+	var eventType string
+	switch activity.Type {
+	case model.ActivityExpenseAdded, model.ActivityExpenseEdited, model.ActivityExpenseDeleted:
+		eventType = StreamExpense
+	case model.ActivityPaymentRecorded:
+		eventType = StreamPayment
+	case model.ActivityMemberLeft:
+		s.hub.Disconnect(activity.GroupID, *activity.UserID)
+		return nil
+	default:
+		return nil
+	}
+
+	if err := s.hub.Publish(activity.GroupID, eventType, activity); err != nil {
+		s.logFailure(activity, err)
+		return nil
+	}
+	if !s.hub.HasSubscribers(activity.GroupID) {
+		return nil
+	}
+
+	balances, err := s.settlements.CalculateBalances(ctx, activity.GroupID)
+	if err == nil {
+		err = s.hub.Publish(activity.GroupID, StreamBalances, balances)
+	}
+	if err != nil {
+		s.logFailure(activity, err)
+	}
+	return nil
+}
+
+func (s *streamService) logFailure(activity *model.Activity, err error) {
+	s.logger.Error("failed to stream activity", slog.Uint64("activity_id", uint64(activity.ID)), slog.Uint64("group_id", uint64(activity.GroupID)), slog.String("error", err.Error()))
+}
@@ -0,0 +1,9 @@
+package service
+
+import "context"
+
+// Transactor runs work in a single database transaction. Repository calls made with the
+// context passed to fn take part in it, and it is rolled back when fn returns an error.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/webhook"
+)
+
+var (
+	ErrInvalidWebhook  = errors.New("invalid webhook: url must be http(s), secret at least 16 characters and events one of expense_added, payment_recorded")
+	ErrWebhookNotFound = errors.New("webhook not found")
+)
+
+const (
+	// webhookMaxAttempts is how many times a delivery is tried before it is marked failed.
+	webhookMaxAttempts = 10
+	// Retries wait webhookRetryBase, doubling after every failure up to webhookRetryMax, which
+	// spreads the ten attempts over about eight and a half hours.
+	webhookRetryBase = time.Minute
+	webhookRetryMax  = 6 * time.Hour
+
+	// webhookBatchSize deliveries are claimed at a time, and the lease must outlast sending
+	// all of them one after another.
+	webhookBatchSize = 20
+	webhookLease     = webhookBatchSize*webhook.DefaultTimeout + time.Minute
+
+	webhookSecretBytes = 32
+	minWebhookSecret   = 16
+)
+
+type WebhookService interface {
+	ActivityQueue
+	// CreateWebhook subscribes a URL to the group's events. When no secret is given one is
+	// generated; either way it is only readable on the returned webhook.
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error)
+	GetWebhooks(ctx context.Context, groupID uint) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, groupID uint, webhookID uint) error
+	// GetDeliveries returns the webhook's most recent deliveries, newest first.
+	GetDeliveries(ctx context.Context, groupID uint, webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	// DeliverDue sends every pending delivery whose next attempt is due at or before now and
+	// returns how many succeeded. It is safe to call concurrently from several instances.
+	DeliverDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type webhookService struct {
+	repo      repository.WebhookRepository
+	groupRepo repository.GroupRepository
+	client    *webhook.Client
+}
+
+func NewWebhookService(repo repository.WebhookRepository, groupRepo repository.GroupRepository, client *webhook.Client) WebhookService {
+	return &webhookService{repo: repo, groupRepo: groupRepo, client: client}
+}
+
+// webhookPayload is the JSON body of every delivery. ID identifies the event, so a receiver
+// can tell a retry of an event it already handled from a new one.
+type webhookPayload struct {
+	ID        uint               `json:"id"`
+	Event     model.ActivityType `json:"event"`
+	GroupID   uint               `json:"group_id"`
+	CreatedAt time.Time          `json:"created_at"`
+	Data      *model.Activity    `json:"data"`
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	callerID, err := requireMember(ctx, s.groupRepo, webhook.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.Secret == "" {
+		if webhook.Secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	if webhook.Events == nil {
+		webhook.Events = []model.ActivityType{}
+	}
+
+	webhook.CreatedByID = callerID
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) GetWebhooks(ctx context.Context, groupID uint) ([]model.Webhook, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetWebhooksByGroupID(ctx, groupID)
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, groupID uint, webhookID uint) error {
+	if _, err := s.getWebhook(ctx, groupID, webhookID); err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhook(ctx, webhookID)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, groupID uint, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := s.getWebhook(ctx, groupID, webhookID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	return s.repo.GetDeliveries(ctx, webhookID, limit)
+}
+
+// getWebhook loads one of the group's webhooks for a member of the group.
+func (s *webhookService) getWebhook(ctx context.Context, groupID uint, webhookID uint) (*model.Webhook, error) {
+	if _, err := requireMember(ctx, s.groupRepo, groupID); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	if webhook.GroupID != groupID {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// Enqueue queues a delivery of the activity for every webhook of its group that subscribes to
+// it. It runs in the write's transaction, so an event is queued exactly when the write commits.
+// Sending is left to DeliverDue, so a slow or broken endpoint never holds up the write.
+func (s *webhookService) Enqueue(ctx context.Context, activity *model.Activity) error {
+	if !slices.Contains(model.WebhookEvents, activity.Type) {
+		return nil
+	}
+
+	webhooks, err := s.repo.GetWebhooksByGroupID(ctx, activity.GroupID)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	var deliveries []model.WebhookDelivery
+	for _, w := range webhooks {
+		if !subscribes(&w, activity.Type) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(webhookPayload{
+				ID:        activity.ID,
+				Event:     activity.Type,
+				GroupID:   activity.GroupID,
+				CreatedAt: activity.CreatedAt,
+				Data:      activity,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookID:     w.ID,
+			ActivityID:    activity.ID,
+			Event:         activity.Type,
+			Payload:       string(payload),
+			Status:        model.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+func (s *webhookService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	delivered := 0
+	var errs []error
+	for ctx.Err() == nil {
+		due, err := s.repo.ClaimDueDeliveries(ctx, now, webhookLease, webhookBatchSize)
+		if err != nil {
+			return delivered, errors.Join(append(errs, err)...)
+		}
+
+		// Deliveries of one batch often go to the same few webhooks
+		webhooks := make(map[uint]*model.Webhook)
+		for i := range due {
+			ok, err := s.deliver(ctx, &due[i], webhooks)
+			if ok {
+				delivered++
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("webhook delivery %d: %w", due[i].ID, err))
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		if len(due) < webhookBatchSize {
+			break
+		}
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+// deliver makes one attempt at a claimed delivery and records the outcome. A failed attempt
+// is not an error, only failing to load the webhook or save the outcome is.
+func (s *webhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery, webhooks map[uint]*model.Webhook) (bool, error) {
+	w, ok := webhooks[delivery.WebhookID]
+	if !ok {
+		var err error
+		if w, err = s.repo.GetWebhookByID(ctx, delivery.WebhookID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Deleted since the delivery was claimed; its deliveries went with it
+				return false, nil
+			}
+			return false, err
+		}
+		webhooks[delivery.WebhookID] = w
+	}
+
+	status, sendErr := s.client.Send(ctx, webhook.Delivery{
+		ID:     delivery.ID,
+		URL:    w.URL,
+		Secret: w.Secret,
+		Event:  string(delivery.Event),
+		Body:   []byte(delivery.Payload),
+	})
+	if sendErr != nil && ctx.Err() != nil {
+		// Shutting down; the lease runs out and the attempt is made again later
+		return false, nil
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	if sendErr == nil {
+		delivery.Status = model.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = sendErr.Error()
+		if delivery.Attempts >= webhookMaxAttempts {
+			delivery.Status = model.DeliveryFailed
+		} else {
+			delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+		}
+	}
+
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return false, err
+	}
+	return sendErr == nil, nil
+}
+
+// webhookBackoff is how long to wait after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookRetryMax)
+}
+
+// subscribes reports whether the webhook wants the event; no events means all of them.
+func subscribes(w *model.Webhook, event model.ActivityType) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+func validateWebhook(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhook
+	}
+	if len(w.Secret) < minWebhookSecret {
+		return ErrInvalidWebhook
+	}
+	for _, event := range w.Events {
+		if !slices.Contains(model.WebhookEvents, event) {
+			return ErrInvalidWebhook
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, webhookRetryMax},
+		{50, webhookRetryMax},
+	} {
+		if got := webhookBackoff(tc.attempts); got != tc.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestWebhookBackoffSpread(t *testing.T) {
+	// The last attempt is made after the waits that follow every earlier one
+	var total time.Duration
+	for attempts := 1; attempts < webhookMaxAttempts; attempts++ {
+		total += webhookBackoff(attempts)
+	}
+	if want := 511 * time.Minute; total != want {
+		t.Fatalf("retries span %v, want %v", total, want)
+	}
+}
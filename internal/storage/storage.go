@@ -0,0 +1,38 @@
+// Package storage keeps binary files such as receipt attachments outside the database.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"expense-tracker/internal/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores opaque blobs under string keys. Keys are made of path-like segments
+// separated by "/" and are chosen by the caller.
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any existing blob.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob stored under key; the caller must close it. It returns ErrNotFound
+	// when there is no such blob.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Open returns the blob store selected in the configuration.
+func Open(cfg *config.AppConfig) (BlobStore, error) {
+	if cfg.AttachmentStore == "s3" {
+		return NewS3Store(S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+		})
+	}
+	return NewLocalStore(cfg.AttachmentDir)
+}
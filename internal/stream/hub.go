@@ -0,0 +1,219 @@
+// Package stream fans live group events out to connected clients.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrClosed = errors.New("stream hub is shut down")
+
+const (
+	// backlogSize events are kept per group so that a client that reconnects with the ID of
+	// the last event it saw gets what it missed instead of starting over.
+	backlogSize = 100
+	// replayWindow is how long a group's backlog outlives its last subscriber.
+	replayWindow = 5 * time.Minute
+	// bufferSize events can queue up for a subscriber; one that falls further behind is
+	// disconnected and catches up from the backlog when it reconnects.
+	bufferSize = 32
+)
+
+// Event is one message on a group's stream. Data is JSON. Events published through the hub
+// have increasing IDs; events with a zero ID are sent to a single client only and carry none.
+type Event struct {
+	ID   uint64
+	Type string
+	Data json.RawMessage
+}
+
+// Subscription receives a group's events until it is closed, either by the subscriber, by
+// the hub shutting down, or because the subscriber fell behind or lost access to the group.
+type Subscription struct {
+	groupID uint
+	userID  uint
+	events  chan Event
+}
+
+// Events is closed when the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+type topic struct {
+	subs map[*Subscription]struct{}
+	// backlog holds the newest events, oldest first. Every event of the group with an ID
+	// above since is in it.
+	backlog []Event
+	since   uint64
+	expiry  *time.Timer
+}
+
+// Hub is an in-process pub/sub of group events. It only keeps what it needs for replay, so
+// each server instance streams the changes made through it.
+type Hub struct {
+	mu     sync.Mutex
+	lastID uint64
+	topics map[uint]*topic
+	closed bool
+}
+
+func NewHub() *Hub {
+	// IDs start from the clock, so those handed out before a restart are never mistaken
+	// for ones of this process
+	return &Hub{lastID: uint64(time.Now().UnixNano()), topics: make(map[uint]*topic)}
+}
+
+// Subscribe starts listening to a group. lastEventID is the client's Last-Event-ID header;
+// when it is set, the events published since are returned for replay, and complete is false
+// if some of them are no longer available and the client has to reload the group instead.
+func (h *Hub) Subscribe(groupID uint, userID uint, lastEventID string) (sub *Subscription, missed []Event, complete bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, false, ErrClosed
+	}
+
+	t := h.topics[groupID]
+	if t == nil {
+		t = &topic{subs: make(map[*Subscription]struct{}), since: h.lastID}
+		h.topics[groupID] = t
+	}
+	if t.expiry != nil {
+		t.expiry.Stop()
+		t.expiry = nil
+	}
+
+	complete = true
+	if lastEventID != "" {
+		last, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil || last < t.since || last > h.lastID {
+			complete = false
+		} else {
+			for _, e := range t.backlog {
+				if e.ID > last {
+					missed = append(missed, e)
+				}
+			}
+		}
+	}
+
+	sub = &Subscription{groupID: groupID, userID: userID, events: make(chan Event, bufferSize)}
+	t.subs[sub] = struct{}{}
+	return sub, missed, complete, nil
+}
+
+// Unsubscribe ends a subscription. It is safe to call more than once.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t := h.topics[sub.groupID]; t != nil {
+		h.remove(t, sub)
+	}
+}
+
+// HasSubscribers reports whether anyone is listening to the group.
+func (h *Hub) HasSubscribers(groupID uint) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topics[groupID]
+	return t != nil && len(t.subs) > 0
+}
+
+// Publish sends an event to the group's subscribers. Nothing is kept for groups nobody has
+// listened to within the replay window.
+func (h *Hub) Publish(groupID uint, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topics[groupID]
+	if h.closed || t == nil {
+		return nil
+	}
+
+	h.lastID++
+	event := Event{ID: h.lastID, Type: eventType, Data: payload}
+
+	if len(t.backlog) == backlogSize {
+		t.since = t.backlog[0].ID
+		t.backlog = append(t.backlog[:0], t.backlog[1:]...)
+	}
+	t.backlog = append(t.backlog, event)
+
+	for sub := range t.subs {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(t, sub)
+		}
+	}
+	return nil
+}
+
+// Disconnect ends the user's subscriptions to the group, e.g. once they have left it.
+func (h *Hub) Disconnect(groupID uint, userID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topics[groupID]
+	if t == nil {
+		return
+	}
+	for sub := range t.subs {
+		if sub.userID == userID {
+			h.remove(t, sub)
+		}
+	}
+}
+
+// Close ends every subscription and rejects new ones, so that open streams finish and the
+// server can shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for groupID, t := range h.topics {
+		for sub := range t.subs {
+			close(sub.events)
+		}
+		if t.expiry != nil {
+			t.expiry.Stop()
+		}
+		delete(h.topics, groupID)
+	}
+}
+
+// remove closes a subscription and, once the group has no subscribers left, schedules its
+// backlog to be dropped. The caller must hold h.mu.
+func (h *Hub) remove(t *topic, sub *Subscription) {
+	if _, ok := t.subs[sub]; !ok {
+		return
+	}
+	delete(t.subs, sub)
+	close(sub.events)
+
+	if len(t.subs) == 0 && t.expiry == nil {
+		t.expiry = time.AfterFunc(replayWindow, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.topics[sub.groupID] == t && len(t.subs) == 0 {
+				delete(h.topics, sub.groupID)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package webhook sends signed event payloads to subscriber URLs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Headers set on every delivery. The signature is "sha256=" followed by the hex HMAC-SHA256,
+// keyed with the webhook's secret, of the timestamp header, a "." and the raw request body.
+// Receivers should recompute it, compare in constant time and reject old timestamps, so that
+// a captured delivery can't be replayed later.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"  // Stays the same across retries, for deduplication
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix seconds when the attempt was made
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// DefaultTimeout bounds a single delivery attempt.
+const DefaultTimeout = 10 * time.Second
+
+// ErrForbiddenAddress is returned for deliveries to a loopback, private or link-local address.
+var ErrForbiddenAddress = errors.New("webhook: refusing to connect to a non-public address")
+
+// Sign returns the signature header value for body sent at timestamp, in Unix seconds.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Delivery is one signed request to a subscriber.
+type Delivery struct {
+	ID     uint
+	URL    string
+	Secret string
+	Event  string
+	Body   []byte
+}
+
+// Client posts deliveries over HTTP. Webhook URLs are chosen by users, so it only connects
+// to public addresses.
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(timeout time.Duration) *Client {
+	// The address is checked as it is dialled, after name resolution, so a host name that
+	// resolves to an internal address is refused too, however often its DNS changes
+	dialer := &net.Dialer{Timeout: timeout, Control: refuseNonPublic}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Client{http: &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// A redirect would silently drop the signature's guarantees about where the payload went
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}
+}
+
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublic(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// isPublic reports whether ip may be reached from the internet at large.
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsMulticast()
+}
+
+// Send posts the delivery and returns the response status. Anything but a 2xx response is
+// returned as an error, together with the status when there was a response at all.
+func (c *Client) Send(ctx context.Context, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "expense-tracker-webhooks")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(d.ID), 10))
+	timestamp := time.Now().Unix()
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Secret, timestamp, d.Body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
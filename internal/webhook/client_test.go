@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	secret := "0123456789abcdef"
+	body := []byte(`{"id":42,"event":"expense_added"}`)
+
+	// HMAC-SHA256 of "1700000000." followed by the body, computed independently
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign(secret, 1700000000, body); got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+	if Sign(secret, 1700000001, body) == want {
+		t.Error("the signature does not depend on the timestamp")
+	}
+	if Sign("another secret!!", 1700000000, body) == want {
+		t.Error("the signature does not depend on the secret")
+	}
+}
+
+func TestSendSignsDelivery(t *testing.T) {
+	delivery := Delivery{ID: 7, Secret: "0123456789abcdef", Event: "payment_recorded", Body: []byte(`{"id":3}`)}
+
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	// The test server listens on loopback, which NewClient refuses to dial
+	client := &Client{http: srv.Client()}
+	delivery.URL = srv.URL
+	status, err := client.Send(context.Background(), delivery)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Send = %d, %v", status, err)
+	}
+
+	if got.Header.Get(HeaderEvent) != "payment_recorded" || got.Header.Get(HeaderDelivery) != "7" {
+		t.Errorf("unexpected headers %v", got.Header)
+	}
+	timestamp, err := strconv.ParseInt(got.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil || time.Since(time.Unix(timestamp, 0)) > time.Minute {
+		t.Fatalf("bad timestamp header %q", got.Header.Get(HeaderTimestamp))
+	}
+	if want := Sign(delivery.Secret, timestamp, gotBody); got.Header.Get(HeaderSignature) != want {
+		t.Errorf("signature %q, want %q", got.Header.Get(HeaderSignature), want)
+	}
+}
+
+func TestSendRefusesNonPublicAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the request reached the loopback server")
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	client := NewClient(time.Second)
+	// localhost only turns into a loopback address once it is resolved
+	for _, url := range []string{srv.URL, "http://localhost:" + port} {
+		_, err := client.Send(context.Background(), Delivery{ID: 1, URL: url, Secret: "s", Event: "e", Body: []byte("{}")})
+		if !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Send to %s: got %v, want ErrForbiddenAddress", url, err)
+		}
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.10":     false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"0.0.0.0":          false,
+		"::ffff:127.0.0.1": false,
+		"224.0.0.1":        false,
+	} {
+		if got := isPublic(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublic(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+-- 003_expense_split_type.sql
+
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS split_type VARCHAR(16) NOT NULL DEFAULT 'exact';
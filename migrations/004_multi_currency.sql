@@ -0,0 +1,7 @@
+-- 004_multi_currency.sql
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS base_currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+-- Value of one unit of the expense currency in the group's base currency, captured at creation time
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS exchange_rate DOUBLE PRECISION NOT NULL DEFAULT 1;
@@ -0,0 +1,41 @@
+-- 006_recurring_expenses.sql
+
+CREATE TABLE IF NOT EXISTS recurring_expenses (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    created_by_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL, -- Stored in cents
+    description TEXT NOT NULL,
+    currency VARCHAR(3), -- Empty means the group's base currency
+    split_type VARCHAR(16) NOT NULL DEFAULT 'exact',
+    frequency VARCHAR(16) NOT NULL, -- daily, weekly, monthly, yearly
+    interval INTEGER NOT NULL DEFAULT 1,
+    day_of_month INTEGER,
+    start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_date TIMESTAMP WITH TIME ZONE,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS recurring_expense_splits (
+    id SERIAL PRIMARY KEY,
+    recurring_expense_id INTEGER NOT NULL REFERENCES recurring_expenses(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL DEFAULT 0,
+    percentage BIGINT NOT NULL DEFAULT 0, -- Basis points
+    shares BIGINT NOT NULL DEFAULT 0
+);
+
+-- One row per materialised occurrence; the primary key makes materialisation idempotent
+CREATE TABLE IF NOT EXISTS recurring_expense_occurrences (
+    recurring_expense_id INTEGER NOT NULL REFERENCES recurring_expenses(id) ON DELETE CASCADE,
+    occurrence_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    expense_id INTEGER REFERENCES expenses(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (recurring_expense_id, occurrence_date)
+);
+
+CREATE INDEX idx_recurring_expenses_group_id ON recurring_expenses(group_id);
+CREATE INDEX idx_recurring_expenses_next_run_at ON recurring_expenses(next_run_at) WHERE active;
@@ -0,0 +1,15 @@
+-- 007_expense_categories.sql
+
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS category VARCHAR(64) NOT NULL DEFAULT 'other';
+CREATE INDEX IF NOT EXISTS idx_expenses_category ON expenses(group_id, category);
+
+ALTER TABLE recurring_expenses ADD COLUMN IF NOT EXISTS category VARCHAR(64);
+
+-- Custom categories a group defines on top of the built-in ones
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (group_id, name)
+);
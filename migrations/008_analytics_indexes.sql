@@ -0,0 +1,5 @@
+-- 008_analytics_indexes.sql
+
+-- Time-bucketed analytics scan a group's rows by date
+CREATE INDEX IF NOT EXISTS idx_expenses_group_created_at ON expenses(group_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_payments_group_created_at ON payments(group_id, created_at);
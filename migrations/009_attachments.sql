@@ -0,0 +1,14 @@
+-- 009_attachments.sql
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    expense_id INTEGER NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type VARCHAR(127) NOT NULL,
+    size BIGINT NOT NULL, -- In bytes
+    storage_key TEXT NOT NULL,
+    uploaded_by_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachments_expense_id ON attachments(expense_id);
@@ -0,0 +1,20 @@
+-- 010_itemized_expenses.sql
+
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS tax BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS tip BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS expense_items (
+    id SERIAL PRIMARY KEY,
+    expense_id INTEGER NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    unit_amount BIGINT NOT NULL, -- Stored in cents
+    quantity BIGINT NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS expense_item_assignees (
+    expense_item_id INTEGER NOT NULL REFERENCES expense_items(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    PRIMARY KEY (expense_item_id, user_id)
+);
+
+CREATE INDEX idx_expense_items_expense_id ON expense_items(expense_id);
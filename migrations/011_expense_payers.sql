@@ -0,0 +1,17 @@
+-- 011_expense_payers.sql
+
+CREATE TABLE IF NOT EXISTS expense_payers (
+    id SERIAL PRIMARY KEY,
+    expense_id INTEGER NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL -- Stored in cents
+);
+
+CREATE INDEX idx_expense_payers_expense_id ON expense_payers(expense_id);
+CREATE INDEX idx_expense_payers_user_id ON expense_payers(user_id);
+
+-- Every existing expense was paid in full by its single payer
+INSERT INTO expense_payers (expense_id, user_id, amount)
+SELECT e.id, e.payer_id, e.amount
+FROM expenses e
+WHERE NOT EXISTS (SELECT 1 FROM expense_payers p WHERE p.expense_id = e.id);
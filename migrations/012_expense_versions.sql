@@ -0,0 +1,48 @@
+-- 012_expense_versions.sql
+
+CREATE TABLE IF NOT EXISTS expense_versions (
+    id SERIAL PRIMARY KEY,
+    -- No foreign key: history is kept after the expense itself is deleted
+    expense_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (expense_id, version)
+);
+
+CREATE INDEX idx_expense_versions_group_id ON expense_versions(group_id);
+
+-- Existing expenses start their history at version 1 with an unknown actor
+INSERT INTO expense_versions (expense_id, group_id, version, action, actor_id, snapshot, created_at)
+SELECT e.id, e.group_id, 1, 'created', NULL,
+       jsonb_build_object(
+           'payer_id', e.payer_id,
+           'payers', COALESCE((SELECT jsonb_agg(jsonb_build_object('user_id', p.user_id, 'amount', p.amount) ORDER BY p.user_id)
+                               FROM expense_payers p WHERE p.expense_id = e.id), '[]'::jsonb),
+           'amount', e.amount,
+           'description', e.description,
+           'category', e.category,
+           'split_type', e.split_type,
+           'currency', e.currency,
+           'exchange_rate', e.exchange_rate,
+           'tax', e.tax,
+           'tip', e.tip,
+           'splits', COALESCE((SELECT jsonb_agg(jsonb_build_object('user_id', s.user_id, 'amount', s.amount) ORDER BY s.user_id)
+                               FROM expense_splits s WHERE s.expense_id = e.id), '[]'::jsonb)
+       )
+       -- Like the snapshots the application writes, only itemized expenses have an items key
+       || COALESCE((SELECT jsonb_build_object('items', jsonb_agg(jsonb_build_object(
+                               'name', i.name,
+                               'unit_amount', i.unit_amount,
+                               'quantity', i.quantity,
+                               'user_ids', COALESCE((SELECT jsonb_agg(a.user_id ORDER BY a.user_id)
+                                                     FROM expense_item_assignees a WHERE a.expense_item_id = i.id), '[]'::jsonb)
+                           ) ORDER BY i.id))
+                    FROM expense_items i WHERE i.expense_id = e.id
+                    HAVING count(*) > 0), '{}'::jsonb),
+       e.created_at
+FROM expenses e
+WHERE NOT EXISTS (SELECT 1 FROM expense_versions v WHERE v.expense_id = e.id);
@@ -0,0 +1,26 @@
+-- 013_activities.sql
+
+-- Append-only: rows are only ever inserted
+CREATE TABLE IF NOT EXISTS activities (
+    id BIGSERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    actor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    user_id INTEGER REFERENCES users(id) ON DELETE RESTRICT,
+    -- No foreign keys to expenses or payments: the feed outlives what it describes
+    expense_id INTEGER,
+    payment_id INTEGER,
+    data JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Feeds page backwards through a group's activities by ID
+CREATE INDEX idx_activities_group_id_id ON activities(group_id, id DESC);
+
+CREATE TABLE IF NOT EXISTS activity_reads (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    last_read_id BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, group_id)
+);
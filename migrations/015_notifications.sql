@@ -0,0 +1,18 @@
+-- 015_notifications.sql
+
+-- Users without a row get every email
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    expense_added BOOLEAN NOT NULL DEFAULT TRUE,
+    payment_received BOOLEAN NOT NULL DEFAULT TRUE,
+    weekly_digest BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per digest sent; the primary key keeps a week's digest from going out twice
+CREATE TABLE IF NOT EXISTS weekly_digests (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    week_start DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, week_start)
+);